@@ -4,6 +4,7 @@ package dispatcher
 
 import (
 	"context"
+	"crypto/tls"
 	"strings"
 	"sync"
 	"time"
@@ -19,6 +20,7 @@ import (
 	"github.com/xtls/xray-core/features/routing"
 	routing_session "github.com/xtls/xray-core/features/routing/session"
 	"github.com/xtls/xray-core/transport"
+	"github.com/xtls/xray-core/transport/internet/stat"
 	"github.com/xtls/xray-core/transport/pipe"
 )
 
@@ -202,6 +204,11 @@ func (d *DefaultDispatcher) Dispatch(ctx context.Context, destination net.Destin
 			result, err := sniffer(ctx, cReader, sniffingRequest.MetadataOnly, destination.Network)
 			if err == nil {
 				content.Protocol = result.Protocol()
+				if result, ok := result.(SnifferResultAttributes); ok {
+					for key, value := range result.Attributes() {
+						content.SetAttribute(key, value)
+					}
+				}
 			}
 			if err == nil && d.shouldOverride(ctx, result, sniffingRequest, destination) {
 				domain := result.Domain()
@@ -252,6 +259,11 @@ func (d *DefaultDispatcher) DispatchLink(ctx context.Context, destination net.De
 		result, err := sniffer(ctx, cReader, sniffingRequest.MetadataOnly, destination.Network)
 		if err == nil {
 			content.Protocol = result.Protocol()
+			if result, ok := result.(SnifferResultAttributes); ok {
+				for key, value := range result.Attributes() {
+					content.SetAttribute(key, value)
+				}
+			}
 		}
 		if err == nil && d.shouldOverride(ctx, result, sniffingRequest, destination) {
 			domain := result.Domain()
@@ -377,8 +389,23 @@ func (d *DefaultDispatcher) routedDispatch(ctx context.Context, link *transport.
 				accessMessage.Detour = inTag + " >> " + tag
 			}
 		}
+		if inbound := session.InboundFromContext(ctx); inbound != nil {
+			accessMessage.TLSResumed = isTLSResumed(inbound.Conn)
+		}
 		log.Record(accessMessage)
 	}
 
 	handler.Dispatch(ctx, link)
 }
+
+// isTLSResumed reports whether conn is a TLS server connection that resumed a
+// previous session.
+func isTLSResumed(conn net.Conn) bool {
+	if counter, ok := conn.(*stat.CounterConnection); ok {
+		conn = counter.Connection
+	}
+	if tlsConn, ok := conn.(interface{ ConnectionState() tls.ConnectionState }); ok {
+		return tlsConn.ConnectionState().DidResume
+	}
+	return false
+}
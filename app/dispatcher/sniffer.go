@@ -120,6 +120,13 @@ func (c compositeResult) ProtocolForDomainResult() string {
 	return c.domainResult.Protocol()
 }
 
+func (c compositeResult) Attributes() map[string]string {
+	if result, ok := c.protocolResult.(SnifferResultAttributes); ok {
+		return result.Attributes()
+	}
+	return nil
+}
+
 type SnifferResultComposite interface {
 	ProtocolForDomainResult() string
 }
@@ -127,3 +134,9 @@ type SnifferResultComposite interface {
 type SnifferIsProtoSubsetOf interface {
 	IsProtoSubsetOf(protocolName string) bool
 }
+
+// SnifferResultAttributes is implemented by sniffing results that carry
+// extra attributes, which are attached to the session content for routing.
+type SnifferResultAttributes interface {
+	Attributes() map[string]string
+}
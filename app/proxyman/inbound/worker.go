@@ -2,10 +2,12 @@ package inbound
 
 import (
 	"context"
+	"time"
 
 	"github.com/xtls/xray-core/app/proxyman"
 	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/protocol"
 	"github.com/xtls/xray-core/common/serial"
 	"github.com/xtls/xray-core/common/session"
 	"github.com/xtls/xray-core/features/routing"
@@ -13,6 +15,7 @@ import (
 	"github.com/xtls/xray-core/transport/internet"
 	"github.com/xtls/xray-core/transport/internet/stat"
 	"github.com/xtls/xray-core/transport/internet/tcp"
+	"github.com/xtls/xray-core/transport/internet/tls"
 )
 
 type worker interface {
@@ -44,6 +47,33 @@ func getTProxyType(s *internet.MemoryStreamConfig) internet.SocketConfig_TProxyM
 	return s.SocketSettings.Tproxy
 }
 
+// clientAuthTimeout bounds the TLS handshake done before the inbound proxy
+// takes over, when client certificates are verified.
+const clientAuthTimeout = 8 * time.Second
+
+// clientCertificateUser completes the TLS handshake of conn, and returns the
+// user of the client certificate, or nil if the client didn't present one.
+func clientCertificateUser(ctx context.Context, conn stat.Connection, config *tls.Config) (*protocol.MemoryUser, error) {
+	tlsConn, ok := conn.(*tls.Conn)
+	if !ok {
+		return nil, newError("client certificates are only supported by TLS over TCP")
+	}
+	ctx, cancel := context.WithTimeout(ctx, clientAuthTimeout)
+	defer cancel()
+	identity, err := tlsConn.ClientIdentity(ctx)
+	if err != nil || identity == "" {
+		return nil, err
+	}
+	u, err := config.LookupClientUser(identity)
+	if err != nil {
+		return nil, err
+	}
+	return &protocol.MemoryUser{
+		Email: u.Email,
+		Level: u.Level,
+	}, nil
+}
+
 func (w *tcpWorker) callback(conn stat.Connection) {
 	ctx, cancel := context.WithCancel(w.ctx)
 	sid := session.NewID()
@@ -69,6 +99,18 @@ func (w *tcpWorker) callback(conn stat.Connection) {
 	}
 	ctx = session.ContextWithOutbound(ctx, outbound)
 
+	var user *protocol.MemoryUser
+	if tlsConfig := tls.ConfigFromStreamSettings(w.stream); tlsConfig.GetClientAuth() != tls.Config_NO_CLIENT_CERT {
+		u, err := clientCertificateUser(ctx, conn, tlsConfig)
+		if err != nil {
+			newError("failed to verify client certificate").Base(err).AtInfo().WriteToLog(session.ExportIDToError(ctx))
+			cancel()
+			conn.Close()
+			return
+		}
+		user = u
+	}
+
 	conn = &stat.CounterConnection{
 		Connection: conn,
 	}
@@ -76,6 +118,7 @@ func (w *tcpWorker) callback(conn stat.Connection) {
 		Source:  net.DestinationFromAddr(conn.RemoteAddr()),
 		Gateway: net.TCPDestination(w.address, w.port),
 		Tag:     w.tag,
+		User:    user,
 		Conn:    conn,
 	})
 
@@ -27,6 +27,7 @@ import (
 type Handler struct {
 	tag             string
 	senderSettings  *proxyman.SenderConfig
+	sourcePool      *sourcePool
 	streamSettings  *internet.MemoryStreamConfig
 	proxy           proxy.Outbound
 	outboundManager outbound.Manager
@@ -56,6 +57,11 @@ func NewHandler(ctx context.Context, config *core.OutboundHandlerConfig) (outbou
 				return nil, newError("failed to parse stream settings").Base(err).AtWarning()
 			}
 			h.streamSettings = mss
+			if len(s.ViaPool) > 0 || s.ViaStrategy == proxyman.SenderConfig_Origin {
+				if h.sourcePool, err = newSourcePool(s); err != nil {
+					return nil, err
+				}
+			}
 		default:
 			return nil, newError("settings is not SenderConfig")
 		}
@@ -191,12 +197,21 @@ out:
 
 // Address implements internet.Dialer.
 func (h *Handler) Address() net.Address {
+	if h.sourcePool != nil {
+		return h.sourcePool.Address()
+	}
 	if h.senderSettings == nil || h.senderSettings.Via == nil {
 		return nil
 	}
 	return h.senderSettings.Via.AsAddress()
 }
 
+// StreamSettings returns the stream settings of the connections dialed by
+// this Handler.
+func (h *Handler) StreamSettings() *internet.MemoryStreamConfig {
+	return h.streamSettings
+}
+
 // Dial implements internet.Dialer.
 func (h *Handler) Dial(ctx context.Context, dest net.Destination) (stat.Connection, error) {
 	if h.senderSettings != nil {
@@ -227,13 +242,17 @@ func (h *Handler) Dial(ctx context.Context, dest net.Destination) (stat.Connecti
 			newError("failed to get outbound handler with tag: ", tag).AtWarning().WriteToLog(session.ExportIDToError(ctx))
 		}
 
-		if h.senderSettings.Via != nil {
+		if h.sourcePool != nil || h.senderSettings.Via != nil {
 			outbound := session.OutboundFromContext(ctx)
 			if outbound == nil {
 				outbound = new(session.Outbound)
 				ctx = session.ContextWithOutbound(ctx, outbound)
 			}
-			outbound.Gateway = h.senderSettings.Via.AsAddress()
+			if h.sourcePool != nil {
+				outbound.Gateway = h.sourcePool.Pick(ctx, dest)
+			} else {
+				outbound.Gateway = h.senderSettings.Via.AsAddress()
+			}
 		}
 	}
 
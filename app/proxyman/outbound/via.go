@@ -0,0 +1,188 @@
+package outbound
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"strings"
+
+	"github.com/xtls/xray-core/app/proxyman"
+	"github.com/xtls/xray-core/common/dice"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/transport/internet"
+)
+
+// sourcePool selects the source address of outbound connections from the
+// configured IPs and CIDRs.
+type sourcePool struct {
+	strategy proxyman.SenderConfig_ViaStrategy
+	prefixes []*net.IPNet
+	// lookupIP resolves domain destinations when the pool mixes address
+	// families, so that the source matches the family of the destination.
+	lookupIP func(ctx context.Context, domain string) ([]net.IP, error)
+}
+
+func newSourcePool(config *proxyman.SenderConfig) (*sourcePool, error) {
+	p := &sourcePool{
+		strategy: config.ViaStrategy,
+		lookupIP: func(ctx context.Context, domain string) ([]net.IP, error) {
+			return internet.LookupIP(ctx, domain, internet.DomainStrategy_USE_IP, nil)
+		},
+	}
+	for _, s := range config.ViaPool {
+		if !strings.Contains(s, "/") {
+			ip := net.ParseIP(s)
+			if ip == nil {
+				return nil, newError("invalid IP in sendThrough: ", s)
+			}
+			if ip4 := ip.To4(); ip4 != nil {
+				ip = ip4
+			}
+			p.prefixes = append(p.prefixes, &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)})
+			continue
+		}
+		_, prefix, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, newError("invalid CIDR in sendThrough: ", s).Base(err)
+		}
+		p.prefixes = append(p.prefixes, prefix)
+	}
+	if len(p.prefixes) == 0 && p.strategy != proxyman.SenderConfig_Origin {
+		return nil, newError("empty sendThrough pool")
+	}
+	return p, nil
+}
+
+// Address returns the address family of the pool as an address, or nil if
+// the pool mixes address families.
+func (p *sourcePool) Address() net.Address {
+	if len(p.prefixes) == 0 {
+		return nil
+	}
+	ipv4 := p.prefixes[0].IP.To4() != nil
+	for _, prefix := range p.prefixes[1:] {
+		if (prefix.IP.To4() != nil) != ipv4 {
+			return nil
+		}
+	}
+	return net.IPAddress(p.prefixes[0].IP)
+}
+
+// Pick returns the source address for a connection to dest, or nil if the
+// connection should not be bound.
+func (p *sourcePool) Pick(ctx context.Context, dest net.Destination) net.Address {
+	inbound := session.InboundFromContext(ctx)
+	var key string
+	switch p.strategy {
+	case proxyman.SenderConfig_Origin:
+		return originAddress(inbound, dest)
+	case proxyman.SenderConfig_Destination:
+		key = dest.Address.String()
+	case proxyman.SenderConfig_User:
+		if inbound != nil && inbound.User != nil {
+			key = inbound.User.Email
+		}
+	}
+
+	prefixes := p.prefixes
+	if hasIPv4, hasIPv6 := p.destinationFamilies(ctx, dest); !hasIPv4 || !hasIPv6 {
+		prefixes = make([]*net.IPNet, 0, len(p.prefixes))
+		for _, prefix := range p.prefixes {
+			if prefix.IP.To4() != nil && hasIPv4 || prefix.IP.To4() == nil && hasIPv6 {
+				prefixes = append(prefixes, prefix)
+			}
+		}
+	}
+	if len(prefixes) == 0 {
+		return nil
+	}
+
+	var seed [32]byte
+	if key != "" {
+		seed = sha256.Sum256([]byte(key))
+	} else {
+		for i := 0; i < len(seed); i += 8 {
+			binary.BigEndian.PutUint64(seed[i:], dice.RollUint64())
+		}
+	}
+	prefix := prefixes[binary.BigEndian.Uint64(seed[:8])%uint64(len(prefixes))]
+	return net.IPAddress(addressInPrefix(prefix, seed[8:]))
+}
+
+// destinationFamilies returns the address families dest can be reached by.
+// Domains are resolved only if the pool mixes families; both families are
+// assumed if they fail to resolve.
+func (p *sourcePool) destinationFamilies(ctx context.Context, dest net.Destination) (hasIPv4, hasIPv6 bool) {
+	if dest.Address.Family().IsIP() {
+		return dest.Address.Family().IsIPv4(), dest.Address.Family().IsIPv6()
+	}
+	if p.Address() != nil {
+		return true, true
+	}
+	ips, err := p.lookupIP(ctx, dest.Address.Domain())
+	if err != nil || len(ips) == 0 {
+		newError("failed to resolve ", dest.Address, " to pick a source address").Base(err).AtDebug().WriteToLog(session.ExportIDToError(ctx))
+		return true, true
+	}
+	for _, ip := range ips {
+		if ip.To4() != nil {
+			hasIPv4 = true
+		} else {
+			hasIPv6 = true
+		}
+	}
+	return
+}
+
+// addressInPrefix fills the host bits of prefix with bits. The network and
+// broadcast addresses of IPv4 subnets are avoided.
+func addressInPrefix(prefix *net.IPNet, bits []byte) net.IP {
+	ip := make(net.IP, len(prefix.IP))
+	allZeros, allOnes := true, true
+	for i := range ip {
+		mask := prefix.Mask[i]
+		ip[i] = prefix.IP[i]&mask | bits[i]&^mask
+		if ip[i]&^mask != 0 {
+			allZeros = false
+		}
+		if ip[i]|mask != 0xff {
+			allOnes = false
+		}
+	}
+	if ones, size := prefix.Mask.Size(); size == 32 && ones < 31 {
+		if allZeros {
+			ip[len(ip)-1] |= 1
+		} else if allOnes {
+			ip[len(ip)-1] &^= 1
+		}
+	}
+	return ip
+}
+
+// originAddress returns the local address of the inbound connection, if it
+// can reach dest.
+func originAddress(inbound *session.Inbound, dest net.Destination) net.Address {
+	if inbound == nil {
+		return nil
+	}
+	var addr net.Address
+	if inbound.Conn != nil {
+		switch local := inbound.Conn.LocalAddr().(type) {
+		case *net.TCPAddr:
+			addr = net.IPAddress(local.IP)
+		case *net.UDPAddr:
+			addr = net.IPAddress(local.IP)
+		}
+	}
+	if addr == nil && inbound.Gateway.IsValid() && inbound.Gateway.Address.Family().IsIP() {
+		addr = inbound.Gateway.Address
+	}
+	if addr == nil || addr == net.AnyIP || addr == net.AnyIPv6 || addr.IP().IsLoopback() {
+		return nil
+	}
+	if dest.Address.Family().IsIP() && addr.Family() != dest.Address.Family() {
+		return nil
+	}
+	return addr
+}
@@ -0,0 +1,97 @@
+package outbound
+
+import (
+	"context"
+	"testing"
+
+	"github.com/xtls/xray-core/app/proxyman"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/protocol"
+	"github.com/xtls/xray-core/common/session"
+)
+
+func TestSourcePool(t *testing.T) {
+	pool, err := newSourcePool(&proxyman.SenderConfig{
+		ViaPool:     []string{"2001:db8::/64", "192.0.2.0/30"},
+		ViaStrategy: proxyman.SenderConfig_Destination,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool.lookupIP = func(ctx context.Context, domain string) ([]net.IP, error) {
+		switch domain {
+		case "ipv4.example":
+			return []net.IP{net.ParseIP("198.51.100.1")}, nil
+		case "ipv6.example":
+			return []net.IP{net.ParseIP("2001:db8:1::1")}, nil
+		}
+		return []net.IP{net.ParseIP("198.51.100.1"), net.ParseIP("2001:db8:1::1")}, nil
+	}
+	_, ipv6, _ := net.ParseCIDR("2001:db8::/64")
+	_, ipv4, _ := net.ParseCIDR("192.0.2.0/30")
+
+	dest := net.TCPDestination(net.DomainAddress("example.com"), 443)
+	first := pool.Pick(context.Background(), dest)
+	for i := 0; i < 10; i++ {
+		if addr := pool.Pick(context.Background(), dest); addr != first {
+			t.Fatal("source of the same destination changed: ", first, " ", addr)
+		}
+	}
+
+	for i := 0; i < 100; i++ {
+		addr := pool.Pick(context.Background(), net.TCPDestination(net.ParseAddress("198.51.100.1"), net.Port(i)))
+		if !ipv4.Contains(addr.IP()) || addr.IP().Equal(net.ParseIP("192.0.2.0")) || addr.IP().Equal(net.ParseIP("192.0.2.3")) {
+			t.Fatal("unexpected IPv4 source: ", addr)
+		}
+		if addr := pool.Pick(context.Background(), net.TCPDestination(net.DomainAddress(string(rune('a'+i%26))+".com"), 443)); !ipv4.Contains(addr.IP()) && !ipv6.Contains(addr.IP()) {
+			t.Fatal("source out of the pool: ", addr)
+		}
+	}
+	if addr := pool.Pick(context.Background(), net.TCPDestination(net.ParseAddress("2001:db8:1::1"), 443)); !ipv6.Contains(addr.IP()) {
+		t.Error("unexpected IPv6 source: ", addr)
+	}
+	for i := 0; i < 10; i++ {
+		if addr := pool.Pick(context.Background(), net.TCPDestination(net.DomainAddress("ipv4.example"), net.Port(i))); !ipv4.Contains(addr.IP()) {
+			t.Fatal("unexpected source of an IPv4 only domain: ", addr)
+		}
+		if addr := pool.Pick(context.Background(), net.TCPDestination(net.DomainAddress("ipv6.example"), net.Port(i))); !ipv6.Contains(addr.IP()) {
+			t.Fatal("unexpected source of an IPv6 only domain: ", addr)
+		}
+	}
+}
+
+func TestSourcePoolUser(t *testing.T) {
+	pool, err := newSourcePool(&proxyman.SenderConfig{
+		ViaPool:     []string{"2001:db8::/64"},
+		ViaStrategy: proxyman.SenderConfig_User,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	userContext := func(email string) context.Context {
+		return session.ContextWithInbound(context.Background(), &session.Inbound{User: &protocol.MemoryUser{Email: email}})
+	}
+	dest := net.TCPDestination(net.DomainAddress("example.com"), 443)
+	if pool.Pick(userContext("a@example.com"), dest) != pool.Pick(userContext("a@example.com"), net.TCPDestination(net.DomainAddress("example.org"), 80)) {
+		t.Error("source of the same user changed")
+	}
+	if pool.Pick(userContext("a@example.com"), dest) == pool.Pick(userContext("b@example.com"), dest) {
+		t.Error("different users share a source")
+	}
+}
+
+func TestSourcePoolOrigin(t *testing.T) {
+	pool, err := newSourcePool(&proxyman.SenderConfig{ViaStrategy: proxyman.SenderConfig_Origin})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := session.ContextWithInbound(context.Background(), &session.Inbound{
+		Gateway: net.TCPDestination(net.ParseAddress("192.0.2.10"), 443),
+	})
+	if addr := pool.Pick(ctx, net.TCPDestination(net.DomainAddress("example.com"), 443)); addr != net.ParseAddress("192.0.2.10") {
+		t.Error("source: ", addr)
+	}
+	if addr := pool.Pick(ctx, net.TCPDestination(net.ParseAddress("2001:db8::1"), 443)); addr != nil {
+		t.Error("bound an IPv6 connection to ", addr)
+	}
+}
@@ -2,7 +2,10 @@ package router
 
 import (
 	"context"
+	"sync"
+	"sync/atomic"
 
+	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/common/dice"
 	"github.com/xtls/xray-core/features/extension"
 	"github.com/xtls/xray-core/features/outbound"
@@ -24,9 +27,14 @@ func (s *RandomStrategy) PickOutbound(tags []string) string {
 }
 
 type Balancer struct {
-	selectors []string
-	strategy  BalancingStrategy
-	ohm       outbound.Manager
+	selectors    []string
+	strategy     BalancingStrategy
+	strategyName string
+	ohm          outbound.Manager
+
+	// picks maps the tags of the picked outbounds to their *atomic.Uint64
+	// counters.
+	picks sync.Map
 }
 
 func (b *Balancer) PickOutbound() (string, error) {
@@ -45,8 +53,32 @@ func (b *Balancer) PickOutbound() (string, error) {
 	return tag, nil
 }
 
+// recordPick counts a route to the picked outbound.
+func (b *Balancer) recordPick(tag string) {
+	counter, found := b.picks.Load(tag)
+	if !found {
+		counter, _ = b.picks.LoadOrStore(tag, new(atomic.Uint64))
+	}
+	counter.(*atomic.Uint64).Add(1)
+}
+
+// pickCounts returns the number of times each outbound was picked.
+func (b *Balancer) pickCounts() map[string]uint64 {
+	counts := make(map[string]uint64)
+	b.picks.Range(func(tag, counter interface{}) bool {
+		counts[tag.(string)] = counter.(*atomic.Uint64).Load()
+		return true
+	})
+	return counts
+}
+
 func (b *Balancer) InjectContext(ctx context.Context) {
 	if contextReceiver, ok := b.strategy.(extension.ContextReceiver); ok {
 		contextReceiver.InjectContext(ctx)
 	}
 }
+
+// Close releases the resources of the balancing strategy.
+func (b *Balancer) Close() error {
+	return common.Close(b.strategy)
+}
@@ -3,6 +3,7 @@ package router
 import (
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/xtls/xray-core/common/net"
 	"github.com/xtls/xray-core/common/strmatcher"
@@ -39,6 +40,29 @@ func (v *ConditionChan) Len() int {
 	return len(*v)
 }
 
+// OrCondition matches if any of its conditions matches.
+type OrCondition []Condition
+
+// Apply implements Condition.
+func (v OrCondition) Apply(ctx routing.Context) bool {
+	for _, cond := range v {
+		if cond.Apply(ctx) {
+			return true
+		}
+	}
+	return false
+}
+
+// NotCondition matches if its condition does not match.
+type NotCondition struct {
+	Condition Condition
+}
+
+// Apply implements Condition.
+func (v *NotCondition) Apply(ctx routing.Context) bool {
+	return !v.Condition.Apply(ctx)
+}
+
 var matcherTypeMap = map[Domain_Type]strmatcher.Type{
 	Domain_Plain:  strmatcher.Substr,
 	Domain_Regex:  strmatcher.Regex,
@@ -82,6 +106,26 @@ func NewMphMatcherGroup(domains []*Domain) (*DomainMatcher, error) {
 	}, nil
 }
 
+// NewHybridMatcherGroup creates a DomainMatcher with a much smaller memory
+// footprint than NewMphMatcherGroup, and slower matching.
+func NewHybridMatcherGroup(domains []*Domain) (*DomainMatcher, error) {
+	g := strmatcher.NewHybridMatcherGroup()
+	for _, d := range domains {
+		matcherType, f := matcherTypeMap[d.Type]
+		if !f {
+			return nil, newError("unsupported domain type", d.Type)
+		}
+		_, err := g.AddPattern(d.Value, matcherType)
+		if err != nil {
+			return nil, err
+		}
+	}
+	g.Build()
+	return &DomainMatcher{
+		matchers: g,
+	}, nil
+}
+
 func NewDomainMatcher(domains []*Domain) (*DomainMatcher, error) {
 	g := new(strmatcher.MatcherGroup)
 	for _, d := range domains {
@@ -110,6 +154,55 @@ func (m *DomainMatcher) Apply(ctx routing.Context) bool {
 	return m.ApplyDomain(domain)
 }
 
+// DomainListMatcher matches the target domain against a domain condition
+// and block lists. The exception rules of a block list only apply to the
+// domains of the list.
+type DomainListMatcher struct {
+	domains Condition
+	lists   []domainListMatcher
+}
+
+type domainListMatcher struct {
+	domains    Condition
+	exceptions Condition
+}
+
+// NewDomainListMatcher builds a DomainListMatcher. domains may be nil, and
+// matcherType is the type of the matchers of the lists.
+func NewDomainListMatcher(domains Condition, lists []*DomainList, matcherType string) (*DomainListMatcher, error) {
+	m := &DomainListMatcher{domains: domains}
+	for _, list := range lists {
+		if len(list.Domain) == 0 {
+			return nil, newError("empty domain list")
+		}
+		l := domainListMatcher{}
+		var err error
+		if l.domains, err = newDomainCondition(matcherType, list.Domain); err != nil {
+			return nil, err
+		}
+		if len(list.Exception) > 0 {
+			if l.exceptions, err = newDomainCondition(matcherType, list.Exception); err != nil {
+				return nil, err
+			}
+		}
+		m.lists = append(m.lists, l)
+	}
+	return m, nil
+}
+
+// Apply implements Condition.
+func (m *DomainListMatcher) Apply(ctx routing.Context) bool {
+	if m.domains != nil && m.domains.Apply(ctx) {
+		return true
+	}
+	for _, l := range m.lists {
+		if l.domains.Apply(ctx) && (l.exceptions == nil || !l.exceptions.Apply(ctx)) {
+			return true
+		}
+	}
+	return false
+}
+
 type MultiGeoIPMatcher struct {
 	matchers []*GeoIPMatcher
 	onSource bool
@@ -220,6 +313,60 @@ func (v *UserMatcher) Apply(ctx routing.Context) bool {
 	return false
 }
 
+type ProcessNameMatcher struct {
+	names []string
+}
+
+func NewProcessNameMatcher(names []string) *ProcessNameMatcher {
+	namesCopy := make([]string, 0, len(names))
+	for _, name := range names {
+		if len(name) > 0 {
+			namesCopy = append(namesCopy, name)
+		}
+	}
+	return &ProcessNameMatcher{
+		names: namesCopy,
+	}
+}
+
+// Apply implements Condition.
+func (v *ProcessNameMatcher) Apply(ctx routing.Context) bool {
+	name := ctx.GetProcessName()
+	if len(name) == 0 {
+		return false
+	}
+	for _, n := range v.names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+type UIDMatcher struct {
+	uids []uint32
+}
+
+func NewUIDMatcher(uids []uint32) *UIDMatcher {
+	return &UIDMatcher{
+		uids: uids,
+	}
+}
+
+// Apply implements Condition.
+func (v *UIDMatcher) Apply(ctx routing.Context) bool {
+	uid, found := ctx.GetUID()
+	if !found {
+		return false
+	}
+	for _, u := range v.uids {
+		if u == uid {
+			return true
+		}
+	}
+	return false
+}
+
 type InboundTagMatcher struct {
 	tags []string
 }
@@ -309,3 +456,78 @@ func (m *AttributeMatcher) Apply(ctx routing.Context) bool {
 	}
 	return m.Match(attributes)
 }
+
+// TimeMatcher matches connections made in time windows of some days of the
+// week.
+type TimeMatcher struct {
+	ranges   []*TimeRange
+	weekdays [7]bool
+	location *time.Location
+	now      func() time.Time
+}
+
+// NewTimeMatcher creates a TimeMatcher of the condition. now returns the
+// current time, and defaults to time.Now.
+func NewTimeMatcher(condition *TimeCondition, now func() time.Time) (*TimeMatcher, error) {
+	m := &TimeMatcher{
+		ranges:   condition.Range,
+		location: time.Local,
+		now:      now,
+	}
+	if m.now == nil {
+		m.now = time.Now
+	}
+	for _, r := range condition.Range {
+		if r.Start > 24*60 || r.End > 24*60 {
+			return nil, newError("invalid time range: ", r.Start, "-", r.End)
+		}
+	}
+	if len(condition.Weekday) == 0 {
+		for i := range m.weekdays {
+			m.weekdays[i] = true
+		}
+	}
+	for _, day := range condition.Weekday {
+		if day > 6 {
+			return nil, newError("invalid weekday: ", day)
+		}
+		m.weekdays[day] = true
+	}
+	if condition.Timezone != "" {
+		location, err := time.LoadLocation(condition.Timezone)
+		if err != nil {
+			return nil, newError("unknown time zone: ", condition.Timezone).Base(err)
+		}
+		m.location = location
+	}
+	return m, nil
+}
+
+// Apply implements Condition.
+func (m *TimeMatcher) Apply(ctx routing.Context) bool {
+	now := m.now().In(m.location)
+	today := now.Weekday()
+	if len(m.ranges) == 0 {
+		return m.weekdays[today]
+	}
+	yesterday := (today + 6) % 7
+	minute := uint32(now.Hour()*60 + now.Minute())
+	for _, r := range m.ranges {
+		switch {
+		case r.Start == r.End:
+			if m.weekdays[today] {
+				return true
+			}
+		case r.Start < r.End:
+			if m.weekdays[today] && minute >= r.Start && minute < r.End {
+				return true
+			}
+		default:
+			// The part after midnight belongs to the window of the day before.
+			if (m.weekdays[today] && minute >= r.Start) || (m.weekdays[yesterday] && minute < r.End) {
+				return true
+			}
+		}
+	}
+	return false
+}
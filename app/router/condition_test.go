@@ -3,8 +3,10 @@ package router_test
 import (
 	"os"
 	"path/filepath"
+	"runtime"
 	"strconv"
 	"testing"
+	"time"
 
 	. "github.com/xtls/xray-core/app/router"
 	"github.com/xtls/xray-core/common"
@@ -106,6 +108,160 @@ func TestRoutingRule(t *testing.T) {
 				},
 			},
 		},
+		{
+			rule: &RoutingRule{
+				Domain: []*Domain{
+					{
+						Value: "example.com",
+						Type:  Domain_Domain,
+					},
+				},
+				Logical: &LogicalRule{
+					Operator: LogicalRule_Not,
+					Rule: []*RoutingRule{
+						{PortList: &net.PortList{Range: []*net.PortRange{net.SinglePortRange(22)}}},
+					},
+				},
+			},
+			test: []ruleTest{
+				{
+					input:  withOutbound(&session.Outbound{Target: net.TCPDestination(net.DomainAddress("example.com"), 80)}),
+					output: true,
+				},
+				{
+					input:  withOutbound(&session.Outbound{Target: net.TCPDestination(net.DomainAddress("example.com"), 22)}),
+					output: false,
+				},
+				{
+					input:  withOutbound(&session.Outbound{Target: net.TCPDestination(net.DomainAddress("example.org"), 80)}),
+					output: false,
+				},
+			},
+		},
+		{
+			rule: &RoutingRule{
+				Logical: &LogicalRule{
+					Operator: LogicalRule_Or,
+					Rule: []*RoutingRule{
+						{UserEmail: []string{"admin@example.com"}},
+						{InboundTag: []string{"office"}},
+					},
+				},
+			},
+			test: []ruleTest{
+				{
+					input:  withInbound(&session.Inbound{User: &protocol.MemoryUser{Email: "admin@example.com"}}),
+					output: true,
+				},
+				{
+					input:  withInbound(&session.Inbound{Tag: "office"}),
+					output: true,
+				},
+				{
+					input:  withInbound(&session.Inbound{Tag: "home"}),
+					output: false,
+				},
+			},
+		},
+		{
+			rule: &RoutingRule{
+				DomainMatcher: "hybrid",
+				Domain: []*Domain{
+					{
+						Value: "example.com",
+						Type:  Domain_Domain,
+					},
+					{
+						Value: "www.google.com",
+						Type:  Domain_Full,
+					},
+					{
+						Value: "^facebook\\.com$",
+						Type:  Domain_Regex,
+					},
+				},
+			},
+			test: []ruleTest{
+				{
+					input:  withOutbound(&session.Outbound{Target: net.TCPDestination(net.DomainAddress("www.Example.com"), 80)}),
+					output: true,
+				},
+				{
+					input:  withOutbound(&session.Outbound{Target: net.TCPDestination(net.DomainAddress("www.google.com"), 80)}),
+					output: true,
+				},
+				{
+					input:  withOutbound(&session.Outbound{Target: net.TCPDestination(net.DomainAddress("mail.google.com"), 80)}),
+					output: false,
+				},
+				{
+					input:  withOutbound(&session.Outbound{Target: net.TCPDestination(net.DomainAddress("facebook.com"), 80)}),
+					output: true,
+				},
+			},
+		},
+		{
+			rule: &RoutingRule{
+				Domain: []*Domain{
+					{
+						Value: "example.org",
+						Type:  Domain_Domain,
+					},
+				},
+				DomainList: []*DomainList{
+					{
+						Domain: []*Domain{
+							{
+								Value: "example.com",
+								Type:  Domain_Domain,
+							},
+						},
+						Exception: []*Domain{
+							{
+								Value: "ok.example.com",
+								Type:  Domain_Domain,
+							},
+							{
+								Value: "ok.example.org",
+								Type:  Domain_Domain,
+							},
+						},
+					},
+					{
+						Domain: []*Domain{
+							{
+								Value: "www.ok.example.com",
+								Type:  Domain_Full,
+							},
+						},
+					},
+				},
+			},
+			test: []ruleTest{
+				{
+					input:  withOutbound(&session.Outbound{Target: net.TCPDestination(net.DomainAddress("ads.example.com"), 80)}),
+					output: true,
+				},
+				{
+					input:  withOutbound(&session.Outbound{Target: net.TCPDestination(net.DomainAddress("api.ok.example.com"), 80)}),
+					output: false,
+				},
+				{
+					// Exceptions of a list don't apply to the other lists.
+					input:  withOutbound(&session.Outbound{Target: net.TCPDestination(net.DomainAddress("www.ok.example.com"), 80)}),
+					output: true,
+				},
+				{
+					// Nor to the domains of the rule.
+					input:  withOutbound(&session.Outbound{Target: net.TCPDestination(net.DomainAddress("www.ok.example.org"), 80)}),
+					output: true,
+				},
+				{
+					input:  withOutbound(&session.Outbound{Target: net.TCPDestination(net.DomainAddress("example.net"), 80)}),
+					output: false,
+				},
+			},
+		},
 		{
 			rule: &RoutingRule{
 				Cidr: []*CIDR{
@@ -414,6 +570,106 @@ func TestChinaSites(t *testing.T) {
 	}
 }
 
+func TestTimeMatcher(t *testing.T) {
+	var now time.Time
+	matcher, err := NewTimeMatcher(&TimeCondition{
+		Range: []*TimeRange{
+			{Start: 9 * 60, End: 18 * 60},
+			{Start: 22 * 60, End: 2 * 60},
+		},
+		Weekday:  []uint32{1, 2, 3, 4, 5},
+		Timezone: "UTC",
+	}, func() time.Time { return now })
+	common.Must(err)
+
+	cases := []struct {
+		time   string
+		output bool
+	}{
+		{"2024-01-08T09:00:00Z", true},       // Monday
+		{"2024-01-08T08:59:59Z", false},      // Monday
+		{"2024-01-08T18:00:00Z", false},      // Monday
+		{"2024-01-08T23:30:00Z", true},       // Monday, in the window across midnight
+		{"2024-01-09T01:59:00Z", true},       // Tuesday
+		{"2024-01-09T02:00:00Z", false},      // Tuesday
+		{"2024-01-08T01:00:00Z", false},      // Monday, in the window of Sunday
+		{"2024-01-13T01:00:00Z", true},       // Saturday, in the window of Friday
+		{"2024-01-13T23:00:00Z", false},      // Saturday
+		{"2024-01-07T12:00:00Z", false},      // Sunday
+		{"2024-01-08T10:00:00+02:00", false}, // 08:00 in UTC
+		{"2024-01-08T11:00:00+02:00", true},
+		{"2024-01-08T09:00:00-08:00", true},
+		{"2024-01-08T10:00:00-08:00", false},
+	}
+	for _, c := range cases {
+		var err error
+		now, err = time.Parse(time.RFC3339, c.time)
+		common.Must(err)
+		if actual := matcher.Apply(withBackground()); actual != c.output {
+			t.Error("time ", c.time, ": ", actual, ", want ", c.output)
+		}
+	}
+
+	wholeDay, err := NewTimeMatcher(&TimeCondition{
+		Range:    []*TimeRange{{Start: 8 * 60, End: 8 * 60}},
+		Weekday:  []uint32{6},
+		Timezone: "UTC",
+	}, func() time.Time { return now })
+	common.Must(err)
+	for _, c := range []struct {
+		time   string
+		output bool
+	}{
+		{"2024-01-13T00:00:00Z", true}, // Saturday
+		{"2024-01-13T07:59:00Z", true},
+		{"2024-01-13T23:59:00Z", true},
+		{"2024-01-14T00:00:00Z", false}, // Sunday
+	} {
+		now, err = time.Parse(time.RFC3339, c.time)
+		common.Must(err)
+		if actual := wholeDay.Apply(withBackground()); actual != c.output {
+			t.Error("time ", c.time, ": ", actual, ", want ", c.output)
+		}
+	}
+
+	if _, err := NewTimeMatcher(&TimeCondition{Weekday: []uint32{7}}, nil); err == nil {
+		t.Error("expected an error for an invalid weekday")
+	}
+}
+
+func TestProcessMatcher(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("process lookup is only supported on Linux")
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	common.Must(err)
+	defer listener.Close()
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	common.Must(err)
+	defer conn.Close()
+
+	exe, err := os.Executable()
+	common.Must(err)
+	uid := uint32(os.Getuid())
+	cases := []struct {
+		rule   *RoutingRule
+		output bool
+	}{
+		{&RoutingRule{ProcessName: []string{"firefox", filepath.Base(exe)}}, true},
+		{&RoutingRule{ProcessName: []string{"firefox"}}, false},
+		{&RoutingRule{Uid: []uint32{uid}}, true},
+		{&RoutingRule{Uid: []uint32{uid + 1}}, false},
+	}
+	for _, c := range cases {
+		cond, err := c.rule.BuildCondition()
+		common.Must(err)
+		ctx := withInbound(&session.Inbound{Source: net.DestinationFromAddr(conn.LocalAddr())})
+		if actual := cond.Apply(ctx); actual != c.output {
+			t.Error("rule ", c.rule, ": ", actual, ", want ", c.output)
+		}
+	}
+}
+
 func BenchmarkMphDomainMatcher(b *testing.B) {
 	domains, err := loadGeoSite("CN")
 	common.Must(err)
@@ -3,6 +3,8 @@ package router
 import (
 	"regexp"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/xtls/xray-core/common/net"
 	"github.com/xtls/xray-core/features/outbound"
@@ -10,9 +12,16 @@ import (
 )
 
 type Rule struct {
-	Tag       string
-	Balancer  *Balancer
-	Condition Condition
+	Tag         string
+	RuleTag     string
+	BalancerTag string
+	Balancer    *Balancer
+	Condition   Condition
+
+	// hits and lastHit are the number of routes picked by the rule, and the
+	// time of the last one in Unix seconds.
+	hits    atomic.Uint64
+	lastHit atomic.Int64
 }
 
 func (r *Rule) GetTag() (string, error) {
@@ -22,6 +31,15 @@ func (r *Rule) GetTag() (string, error) {
 	return r.Tag, nil
 }
 
+// hit records a route picked by the rule. The time of the last hit is in
+// Unix seconds, and only stored once a second, so that routes mostly read it.
+func (r *Rule) hit() {
+	r.hits.Add(1)
+	if now := time.Now().Unix(); r.lastHit.Load() != now {
+		r.lastHit.Store(now)
+	}
+}
+
 // Apply checks rule matching of current routing context.
 func (r *Rule) Apply(ctx routing.Context) bool {
 	return r.Condition.Apply(ctx)
@@ -30,24 +48,36 @@ func (r *Rule) Apply(ctx routing.Context) bool {
 func (rr *RoutingRule) BuildCondition() (Condition, error) {
 	conds := NewConditionChan()
 
+	var domainCond Condition
 	if len(rr.Domain) > 0 {
-		switch rr.DomainMatcher {
-		case "linear":
-			matcher, err := NewDomainMatcher(rr.Domain)
-			if err != nil {
-				return nil, newError("failed to build domain condition").Base(err)
-			}
-			conds.Add(matcher)
-		case "mph", "hybrid":
-			fallthrough
-		default:
-			matcher, err := NewMphMatcherGroup(rr.Domain)
-			if err != nil {
-				return nil, newError("failed to build domain condition with MphDomainMatcher").Base(err)
+		cond, err := newDomainCondition(rr.DomainMatcher, rr.Domain)
+		if err != nil {
+			return nil, err
+		}
+		domainCond = cond
+	}
+	if len(rr.DomainRuleset) > 0 {
+		matcherType := rr.DomainMatcher
+		cond, err := NewRuleSetCondition("domain", rr.DomainRuleset, func(rs *RuleSet) (Condition, error) {
+			if len(rs.CIDRs) > 0 {
+				return nil, newError("CIDRs in domain rule set")
 			}
-			newError("MphDomainMatcher is enabled for ", len(rr.Domain), " domain rule(s)").AtDebug().WriteToLog()
-			conds.Add(matcher)
+			return newDomainCondition(matcherType, rs.Domains)
+		})
+		if err != nil {
+			return nil, newError("failed to build domain condition").Base(err)
 		}
+		domainCond = orRuleSetCondition(domainCond, cond)
+	}
+	if len(rr.DomainList) > 0 {
+		cond, err := NewDomainListMatcher(domainCond, rr.DomainList, rr.DomainMatcher)
+		if err != nil {
+			return nil, newError("failed to build domain list condition").Base(err)
+		}
+		domainCond = cond
+	}
+	if domainCond != nil {
+		conds.Add(domainCond)
 	}
 
 	if len(rr.UserEmail) > 0 {
@@ -74,31 +104,15 @@ func (rr *RoutingRule) BuildCondition() (Condition, error) {
 		conds.Add(NewNetworkMatcher(rr.NetworkList.Network))
 	}
 
-	if len(rr.Geoip) > 0 {
-		cond, err := NewMultiGeoIPMatcher(rr.Geoip, false)
-		if err != nil {
-			return nil, err
-		}
-		conds.Add(cond)
-	} else if len(rr.Cidr) > 0 {
-		cond, err := NewMultiGeoIPMatcher([]*GeoIP{{Cidr: rr.Cidr}}, false)
-		if err != nil {
-			return nil, err
-		}
+	if cond, err := newIPCondition("ip", rr.Geoip, rr.Cidr, rr.IpRuleset, false); err != nil {
+		return nil, err
+	} else if cond != nil {
 		conds.Add(cond)
 	}
 
-	if len(rr.SourceGeoip) > 0 {
-		cond, err := NewMultiGeoIPMatcher(rr.SourceGeoip, true)
-		if err != nil {
-			return nil, err
-		}
-		conds.Add(cond)
-	} else if len(rr.SourceCidr) > 0 {
-		cond, err := NewMultiGeoIPMatcher([]*GeoIP{{Cidr: rr.SourceCidr}}, true)
-		if err != nil {
-			return nil, err
-		}
+	if cond, err := newIPCondition("source", rr.SourceGeoip, rr.SourceCidr, rr.SourceRuleset, true); err != nil {
+		return nil, err
+	} else if cond != nil {
 		conds.Add(cond)
 	}
 
@@ -114,6 +128,32 @@ func (rr *RoutingRule) BuildCondition() (Condition, error) {
 		conds.Add(&AttributeMatcher{configuredKeys})
 	}
 
+	if rr.Time != nil {
+		cond, err := NewTimeMatcher(rr.Time, nil)
+		if err != nil {
+			return nil, newError("failed to build time condition").Base(err)
+		}
+		conds.Add(cond)
+	}
+
+	if rr.Logical != nil {
+		cond, err := rr.Logical.BuildCondition()
+		if err != nil {
+			return nil, err
+		}
+		conds.Add(cond)
+	}
+
+	// Looking up the process of a connection scans /proc, so it is only done
+	// if the cheaper conditions match.
+	if len(rr.ProcessName) > 0 {
+		conds.Add(NewProcessNameMatcher(rr.ProcessName))
+	}
+
+	if len(rr.Uid) > 0 {
+		conds.Add(NewUIDMatcher(rr.Uid))
+	}
+
 	if conds.Len() == 0 {
 		return nil, newError("this rule has no effective fields").AtWarning()
 	}
@@ -121,21 +161,122 @@ func (rr *RoutingRule) BuildCondition() (Condition, error) {
 	return conds, nil
 }
 
+// BuildCondition builds a tree of the conditions of the sub-rules.
+func (lr *LogicalRule) BuildCondition() (Condition, error) {
+	conds := make([]Condition, 0, len(lr.Rule))
+	for _, rule := range lr.Rule {
+		cond, err := rule.BuildCondition()
+		if err != nil {
+			return nil, newError("failed to build sub-rule of logical rule").Base(err)
+		}
+		conds = append(conds, cond)
+	}
+	if len(conds) == 0 {
+		return nil, newError("logical rule has no sub-rules")
+	}
+
+	switch lr.Operator {
+	case LogicalRule_And:
+		chain := ConditionChan(conds)
+		return &chain, nil
+	case LogicalRule_Or:
+		return OrCondition(conds), nil
+	case LogicalRule_Not:
+		if len(conds) != 1 {
+			return nil, newError("not rule should have exactly one sub-rule, but got ", len(conds))
+		}
+		return &NotCondition{Condition: conds[0]}, nil
+	default:
+		return nil, newError("unknown operator of logical rule: ", lr.Operator)
+	}
+}
+
+func newDomainCondition(matcherType string, domains []*Domain) (Condition, error) {
+	switch matcherType {
+	case "linear":
+		matcher, err := NewDomainMatcher(domains)
+		if err != nil {
+			return nil, newError("failed to build domain condition").Base(err)
+		}
+		return matcher, nil
+	case "hybrid":
+		matcher, err := NewHybridMatcherGroup(domains)
+		if err != nil {
+			return nil, newError("failed to build domain condition with HybridDomainMatcher").Base(err)
+		}
+		newError("HybridDomainMatcher is enabled for ", len(domains), " domain rule(s)").AtDebug().WriteToLog()
+		return matcher, nil
+	case "mph":
+		fallthrough
+	default:
+		if len(domains) == 0 {
+			// An empty rule set matches nothing.
+			return NewDomainMatcher(nil)
+		}
+		matcher, err := NewMphMatcherGroup(domains)
+		if err != nil {
+			return nil, newError("failed to build domain condition with MphDomainMatcher").Base(err)
+		}
+		newError("MphDomainMatcher is enabled for ", len(domains), " domain rule(s)").AtDebug().WriteToLog()
+		return matcher, nil
+	}
+}
+
+// newIPCondition builds the condition of the destination or source IP
+// fields of a rule. It returns nil if the fields are empty.
+func newIPCondition(name string, geoips []*GeoIP, cidrs []*CIDR, ruleSets []string, onSource bool) (Condition, error) {
+	if len(geoips) == 0 && len(cidrs) > 0 {
+		geoips = []*GeoIP{{Cidr: cidrs}}
+	}
+	var ipCond Condition
+	if len(geoips) > 0 {
+		cond, err := NewMultiGeoIPMatcher(geoips, onSource)
+		if err != nil {
+			return nil, err
+		}
+		ipCond = cond
+	}
+	if len(ruleSets) > 0 {
+		cond, err := NewRuleSetCondition(name, ruleSets, func(rs *RuleSet) (Condition, error) {
+			if len(rs.Domains) > 0 {
+				return nil, newError("domains in ", name, " rule set")
+			}
+			return NewMultiGeoIPMatcher([]*GeoIP{{Cidr: rs.CIDRs}}, onSource)
+		})
+		if err != nil {
+			return nil, newError("failed to build ", name, " condition").Base(err)
+		}
+		ipCond = orRuleSetCondition(ipCond, cond)
+	}
+	return ipCond, nil
+}
+
+// orRuleSetCondition matches static or the rule set condition, so that
+// reloads of the rule set files do not rebuild the static matcher.
+func orRuleSetCondition(static Condition, ruleSet *RuleSetCondition) Condition {
+	if static == nil {
+		return ruleSet
+	}
+	return OrCondition{static, ruleSet}
+}
+
 func (br *BalancingRule) Build(ohm outbound.Manager) (*Balancer, error) {
 	switch br.Strategy {
 	case "leastPing":
 		return &Balancer{
-			selectors: br.OutboundSelector,
-			strategy:  &LeastPingStrategy{},
-			ohm:       ohm,
+			selectors:    br.OutboundSelector,
+			strategy:     &LeastPingStrategy{},
+			strategyName: br.Strategy,
+			ohm:          ohm,
 		}, nil
 	case "random":
 		fallthrough
 	default:
 		return &Balancer{
-			selectors: br.OutboundSelector,
-			strategy:  &RandomStrategy{},
-			ohm:       ohm,
+			selectors:    br.OutboundSelector,
+			strategy:     &RandomStrategy{},
+			strategyName: "random",
+			ohm:          ohm,
 		}, nil
 
 	}
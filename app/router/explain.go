@@ -0,0 +1,132 @@
+package router
+
+import (
+	"fmt"
+
+	"github.com/xtls/xray-core/features/routing"
+)
+
+// RuleTrace is the result of applying a rule to a routing context.
+type RuleTrace struct {
+	// Index of the rule in the rule table.
+	Index       int
+	RuleTag     string
+	OutboundTag string
+	BalancerTag string
+
+	Matched bool
+	// RejectedBy names the condition of the rule that didn't match, such as
+	// "domain" or "port".
+	RejectedBy string
+}
+
+// Explanation describes how a Router routes a routing context.
+type Explanation struct {
+	// Traces are the rules applied in order, ending with the matched rule if
+	// any. Rules are applied twice if the domain strategy is IpIfNonMatch.
+	Traces []*RuleTrace
+	// Matched is the trace of the matched rule, or nil if no rule matches.
+	Matched *RuleTrace
+	// OutboundTag is the picked outbound, which is empty if no rule matches.
+	OutboundTag string
+}
+
+// Explain routes ctx like PickRoute and records why each rule matched or not.
+func (r *Router) Explain(ctx routing.Context) (*Explanation, error) {
+	explanation := new(Explanation)
+	rules := r.table.Load().rules
+
+	apply := func() *Rule {
+		for i, rule := range rules {
+			trace := &RuleTrace{
+				Index:       i,
+				RuleTag:     rule.RuleTag,
+				BalancerTag: rule.BalancerTag,
+			}
+			if rule.Balancer == nil {
+				trace.OutboundTag = rule.Tag
+			}
+			explanation.Traces = append(explanation.Traces, trace)
+			if cond := rejectingCondition(rule.Condition, ctx); cond != nil {
+				trace.RejectedBy = conditionName(cond)
+				continue
+			}
+			trace.Matched = true
+			explanation.Matched = trace
+			return rule
+		}
+		return nil
+	}
+
+	rule := apply()
+	if rule == nil && r.domainStrategy == Config_IpIfNonMatch && len(ctx.GetTargetDomain()) > 0 && !ctx.GetSkipDNSResolve() {
+		rule = apply()
+	}
+	if rule == nil {
+		return explanation, nil
+	}
+	tag, err := rule.GetTag()
+	if err != nil {
+		return explanation, err
+	}
+	explanation.OutboundTag = tag
+	return explanation, nil
+}
+
+// rejectingCondition returns the first condition in cond that doesn't match
+// ctx, or nil if cond matches.
+func rejectingCondition(cond Condition, ctx routing.Context) Condition {
+	if conds, ok := cond.(*ConditionChan); ok {
+		for _, c := range *conds {
+			if rejected := rejectingCondition(c, ctx); rejected != nil {
+				return rejected
+			}
+		}
+		return nil
+	}
+	if !cond.Apply(ctx) {
+		return cond
+	}
+	return nil
+}
+
+// conditionName returns the name of cond in routing rules of config files.
+func conditionName(cond Condition) string {
+	switch c := cond.(type) {
+	case *DomainMatcher, *DomainListMatcher:
+		return "domain"
+	case *MultiGeoIPMatcher:
+		if c.onSource {
+			return "source"
+		}
+		return "ip"
+	case *PortMatcher:
+		if c.onSource {
+			return "sourcePort"
+		}
+		return "port"
+	case NetworkMatcher:
+		return "network"
+	case *UserMatcher:
+		return "user"
+	case *InboundTagMatcher:
+		return "inboundTag"
+	case *ProcessNameMatcher:
+		return "process"
+	case *UIDMatcher:
+		return "uid"
+	case *ProtocolMatcher:
+		return "protocol"
+	case *AttributeMatcher:
+		return "attrs"
+	case *TimeMatcher:
+		return "time"
+	case OrCondition:
+		return "logical or"
+	case *NotCondition:
+		return "logical not"
+	case fmt.Stringer:
+		return c.String()
+	}
+	return fmt.Sprintf("%T", cond)
+}
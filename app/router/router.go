@@ -4,18 +4,44 @@ package router
 
 import (
 	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/serial"
+	"github.com/xtls/xray-core/common/task"
 	"github.com/xtls/xray-core/core"
 	"github.com/xtls/xray-core/features/outbound"
 	"github.com/xtls/xray-core/features/routing"
 )
 
+var _ routing.RuleManager = (*Router)(nil)
+
 // Router is an implementation of routing.Router.
 type Router struct {
 	domainStrategy Config_DomainStrategy
-	rules          []*Rule
-	balancers      map[string]*Balancer
+	ctx            context.Context
+	ohm            outbound.Manager
+
+	// access serializes changes of the rule table. Routes are picked from the
+	// current table without locking.
+	access sync.Mutex
+	table  atomic.Pointer[ruleTable]
+
+	// ruleSetTask reloads the rule set files of the rules when they change.
+	ruleSetTask *task.Periodic
+}
+
+// replacedBalancerCloseDelay is the time replaced balancers are kept open for
+// routes that are still being picked with the rules before the replacement.
+const replacedBalancerCloseDelay = 10 * time.Second
+
+// ruleTable is an immutable set of rules and the balancers they refer to.
+type ruleTable struct {
+	rules     []*Rule
+	balancers map[string]*Balancer
 }
 
 // Route is an implementation of routing.Route.
@@ -28,19 +54,64 @@ type Route struct {
 // Init initializes the Router.
 func (r *Router) Init(ctx context.Context, config *Config, ohm outbound.Manager) error {
 	r.domainStrategy = config.DomainStrategy
+	r.ctx = ctx
+	r.ohm = ohm
+	r.ruleSetTask = &task.Periodic{
+		Interval: ruleSetCheckInterval,
+		Execute: func() error {
+			reloadRuleSets(r.table.Load().rules)
+			return nil
+		},
+	}
+
+	table := &ruleTable{
+		balancers: make(map[string]*Balancer, len(config.BalancingRule)),
+	}
+	if err := r.addToTable(table, config, true); err != nil {
+		return err
+	}
+	r.table.Store(table)
+	return nil
+}
+
+// addToTable builds the balancers and rules of config into table. The
+// balancers built are closed if it fails.
+func (r *Router) addToTable(table *ruleTable, config *Config, shouldAppend bool) (err error) {
+	built := make(map[string]*Balancer, len(config.BalancingRule))
+	defer func() {
+		if err != nil {
+			closeBalancers(built)
+		}
+	}()
 
-	r.balancers = make(map[string]*Balancer, len(config.BalancingRule))
 	for _, rule := range config.BalancingRule {
-		balancer, err := rule.Build(ohm)
+		if _, found := table.balancers[rule.Tag]; found {
+			return newError("duplicate balancer tag ", rule.Tag)
+		}
+		balancer, err := rule.Build(r.ohm)
 		if err != nil {
 			return err
 		}
-		balancer.InjectContext(ctx)
-		r.balancers[rule.Tag] = balancer
+		balancer.InjectContext(r.ctx)
+		table.balancers[rule.Tag] = balancer
+		built[rule.Tag] = balancer
 	}
 
-	r.rules = make([]*Rule, 0, len(config.Rule))
+	ruleTags := make(map[string]bool, len(table.rules)+len(config.Rule))
+	for _, rule := range table.rules {
+		if rule.RuleTag != "" {
+			ruleTags[rule.RuleTag] = true
+		}
+	}
+
+	rules := make([]*Rule, 0, len(config.Rule))
 	for _, rule := range config.Rule {
+		if rule.RuleTag != "" {
+			if ruleTags[rule.RuleTag] {
+				return newError("duplicate rule tag ", rule.RuleTag)
+			}
+			ruleTags[rule.RuleTag] = true
+		}
 		cond, err := rule.BuildCondition()
 		if err != nil {
 			return err
@@ -48,21 +119,168 @@ func (r *Router) Init(ctx context.Context, config *Config, ohm outbound.Manager)
 		rr := &Rule{
 			Condition: cond,
 			Tag:       rule.GetTag(),
+			RuleTag:   rule.RuleTag,
 		}
 		btag := rule.GetBalancingTag()
 		if len(btag) > 0 {
-			brule, found := r.balancers[btag]
+			brule, found := table.balancers[btag]
 			if !found {
 				return newError("balancer ", btag, " not found")
 			}
 			rr.Balancer = brule
+			rr.BalancerTag = btag
 		}
-		r.rules = append(r.rules, rr)
+		rules = append(rules, rr)
 	}
 
+	if shouldAppend {
+		table.rules = append(table.rules, rules...)
+	} else {
+		table.rules = append(rules, table.rules...)
+	}
 	return nil
 }
 
+// update applies change to a copy of the current rule table, and makes the
+// copy current if change succeeds.
+func (r *Router) update(change func(*ruleTable) error) error {
+	r.access.Lock()
+	defer r.access.Unlock()
+
+	current := r.table.Load()
+	table := &ruleTable{
+		rules:     make([]*Rule, len(current.rules)),
+		balancers: make(map[string]*Balancer, len(current.balancers)),
+	}
+	copy(table.rules, current.rules)
+	for tag, balancer := range current.balancers {
+		table.balancers[tag] = balancer
+	}
+	if err := change(table); err != nil {
+		return err
+	}
+	r.table.Store(table)
+	return nil
+}
+
+func routerConfig(msg *serial.TypedMessage) (*Config, error) {
+	if msg == nil {
+		return nil, newError("empty router config")
+	}
+	inst, err := msg.GetInstance()
+	if err != nil {
+		return nil, err
+	}
+	config, ok := inst.(*Config)
+	if !ok {
+		return nil, newError("not a router config: ", msg.Type)
+	}
+	return config, nil
+}
+
+// AddRule implements routing.RuleManager.
+func (r *Router) AddRule(msg *serial.TypedMessage, shouldAppend bool) error {
+	config, err := routerConfig(msg)
+	if err != nil {
+		return err
+	}
+	return r.update(func(table *ruleTable) error {
+		return r.addToTable(table, config, shouldAppend)
+	})
+}
+
+// RemoveRule implements routing.RuleManager.
+func (r *Router) RemoveRule(ruleTag string) error {
+	if ruleTag == "" {
+		return newError("empty rule tag")
+	}
+	return r.update(func(table *ruleTable) error {
+		rules := table.rules[:0]
+		for _, rule := range table.rules {
+			if rule.RuleTag != ruleTag {
+				rules = append(rules, rule)
+			}
+		}
+		if len(rules) == len(table.rules) {
+			return newError("rule ", ruleTag, " not found")
+		}
+		table.rules = rules
+		return nil
+	})
+}
+
+// ReplaceRules implements routing.RuleManager.
+func (r *Router) ReplaceRules(msg *serial.TypedMessage) error {
+	config, err := routerConfig(msg)
+	if err != nil {
+		return err
+	}
+	var replaced map[string]*Balancer
+	err = r.update(func(table *ruleTable) error {
+		replaced = table.balancers
+		table.rules = nil
+		table.balancers = make(map[string]*Balancer, len(config.BalancingRule))
+		return r.addToTable(table, config, true)
+	})
+	if err != nil {
+		return err
+	}
+	// The new rules only refer to the new balancers, but routes being picked
+	// with the old rules may still use the replaced ones for a while.
+	time.AfterFunc(replacedBalancerCloseDelay, func() {
+		closeBalancers(replaced)
+	})
+	return nil
+}
+
+func closeBalancers(balancers map[string]*Balancer) {
+	for tag, balancer := range balancers {
+		if err := balancer.Close(); err != nil {
+			newError("failed to close balancer ", tag).Base(err).AtWarning().WriteToLog()
+		}
+	}
+}
+
+// ListRules implements routing.RuleManager.
+func (r *Router) ListRules() []*routing.RuleInfo {
+	table := r.table.Load()
+	infos := make([]*routing.RuleInfo, 0, len(table.rules))
+	for i, rule := range table.rules {
+		info := &routing.RuleInfo{
+			Index:       i,
+			RuleTag:     rule.RuleTag,
+			BalancerTag: rule.BalancerTag,
+			Hits:        rule.hits.Load(),
+		}
+		if rule.Balancer == nil {
+			info.OutboundTag = rule.Tag
+		}
+		if lastHit := rule.lastHit.Load(); lastHit != 0 {
+			info.LastHit = time.Unix(lastHit, 0)
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// ListBalancers implements routing.RuleManager.
+func (r *Router) ListBalancers() []*routing.BalancerInfo {
+	table := r.table.Load()
+	infos := make([]*routing.BalancerInfo, 0, len(table.balancers))
+	for tag, balancer := range table.balancers {
+		infos = append(infos, &routing.BalancerInfo{
+			Tag:       tag,
+			Selectors: balancer.selectors,
+			Strategy:  balancer.strategyName,
+			Picks:     balancer.pickCounts(),
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Tag < infos[j].Tag
+	})
+	return infos
+}
+
 // PickRoute implements routing.Router.
 func (r *Router) PickRoute(ctx routing.Context) (routing.Route, error) {
 	rule, ctx, err := r.pickRouteInternal(ctx)
@@ -73,6 +291,10 @@ func (r *Router) PickRoute(ctx routing.Context) (routing.Route, error) {
 	if err != nil {
 		return nil, err
 	}
+	rule.hit()
+	if rule.Balancer != nil {
+		rule.Balancer.recordPick(tag)
+	}
 	return &Route{Context: ctx, outboundTag: tag}, nil
 }
 
@@ -82,7 +304,8 @@ func (r *Router) pickRouteInternal(ctx routing.Context) (*Rule, routing.Context,
 	// this prevents cycle resolving dead loop
 	skipDNSResolve := ctx.GetSkipDNSResolve()
 
-	for _, rule := range r.rules {
+	rules := r.table.Load().rules
+	for _, rule := range rules {
 		if rule.Apply(ctx) {
 			return rule, ctx, nil
 		}
@@ -93,7 +316,7 @@ func (r *Router) pickRouteInternal(ctx routing.Context) (*Rule, routing.Context,
 	}
 
 	// Try applying rules again if we have IPs.
-	for _, rule := range r.rules {
+	for _, rule := range rules {
 		if rule.Apply(ctx) {
 			return rule, ctx, nil
 		}
@@ -103,13 +326,14 @@ func (r *Router) pickRouteInternal(ctx routing.Context) (*Rule, routing.Context,
 }
 
 // Start implements common.Runnable.
-func (*Router) Start() error {
-	return nil
+func (r *Router) Start() error {
+	return r.ruleSetTask.Start()
 }
 
 // Close implements common.Closable.
-func (*Router) Close() error {
-	return nil
+func (r *Router) Close() error {
+	closeBalancers(r.table.Load().balancers)
+	return r.ruleSetTask.Close()
 }
 
 // Type implements common.HasType.
@@ -0,0 +1,260 @@
+package router_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/xtls/xray-core/app/router"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/serial"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/features/outbound"
+	"github.com/xtls/xray-core/features/routing"
+)
+
+func pickTag(r *Router, dest net.Destination) string {
+	route, err := r.PickRoute(withOutbound(&session.Outbound{Target: dest}))
+	if err != nil {
+		return ""
+	}
+	return route.GetOutboundTag()
+}
+
+func TestRuleManager(t *testing.T) {
+	domainRule := &RoutingRule{
+		RuleTag:   "domain",
+		TargetTag: &RoutingRule_Tag{Tag: "direct"},
+		Domain:    []*Domain{{Type: Domain_Domain, Value: "example.com"}},
+	}
+	portRule := &RoutingRule{
+		RuleTag:   "port",
+		TargetTag: &RoutingRule_Tag{Tag: "proxy"},
+		PortList:  &net.PortList{Range: []*net.PortRange{net.SinglePortRange(443)}},
+	}
+
+	r := new(Router)
+	common.Must(r.Init(context.Background(), &Config{Rule: []*RoutingRule{domainRule}}, nil))
+	var manager routing.RuleManager = r
+
+	example := net.TCPDestination(net.DomainAddress("www.example.com"), 443)
+	other := net.TCPDestination(net.DomainAddress("www.example.org"), 443)
+	if tag := pickTag(r, other); tag != "" {
+		t.Error("unexpected route before adding rule: ", tag)
+	}
+
+	// Routes are picked concurrently with the changes below.
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				pickTag(r, example)
+			}
+		}
+	}()
+
+	common.Must(manager.AddRule(serial.ToTypedMessage(&Config{Rule: []*RoutingRule{portRule}}), false))
+	if tag := pickTag(r, example); tag != "proxy" {
+		t.Error("rule is not prepended: ", tag)
+	}
+	if err := manager.AddRule(serial.ToTypedMessage(&Config{Rule: []*RoutingRule{portRule}}), true); err == nil {
+		t.Error("expected an error for a duplicate rule tag")
+	}
+	common.Must(manager.RemoveRule("port"))
+	if tag := pickTag(r, example); tag != "direct" {
+		t.Error("unexpected route after removing rule: ", tag)
+	}
+	if err := manager.RemoveRule("port"); err == nil {
+		t.Error("expected an error when removing a missing rule")
+	}
+
+	common.Must(manager.AddRule(serial.ToTypedMessage(&Config{
+		Rule:          []*RoutingRule{{RuleTag: "balanced", TargetTag: &RoutingRule_BalancingTag{BalancingTag: "b"}, PortList: portRule.PortList}},
+		BalancingRule: []*BalancingRule{{Tag: "b", OutboundSelector: []string{"proxy"}}},
+	}), true))
+	if err := manager.AddRule(serial.ToTypedMessage(&Config{
+		BalancingRule: []*BalancingRule{{Tag: "b", OutboundSelector: []string{"proxy"}}},
+	}), true); err == nil {
+		t.Error("expected an error for a duplicate balancer")
+	}
+	rules := manager.ListRules()
+	if len(rules) != 2 || rules[0].RuleTag != "domain" || rules[0].OutboundTag != "direct" || rules[1].BalancerTag != "b" {
+		t.Error("unexpected rules")
+	}
+	if balancers := manager.ListBalancers(); len(balancers) != 1 || balancers[0].Tag != "b" || balancers[0].Strategy != "random" {
+		t.Error("unexpected balancers")
+	}
+
+	common.Must(manager.ReplaceRules(serial.ToTypedMessage(&Config{Rule: []*RoutingRule{portRule}})))
+	if tag := pickTag(r, other); tag != "proxy" {
+		t.Error("unexpected route after replacing rules: ", tag)
+	}
+	if len(manager.ListRules()) != 1 || len(manager.ListBalancers()) != 0 {
+		t.Error("rules are not replaced")
+	}
+	if err := manager.ReplaceRules(serial.ToTypedMessage(&Config{Rule: []*RoutingRule{{TargetTag: &RoutingRule_BalancingTag{BalancingTag: "missing"}, PortList: portRule.PortList}}})); err == nil {
+		t.Error("expected an error for a missing balancer")
+	}
+	if tag := pickTag(r, other); tag != "proxy" {
+		t.Error("a failed change modified the rules: ", tag)
+	}
+
+	close(stop)
+	wg.Wait()
+}
+
+func TestExplain(t *testing.T) {
+	r := new(Router)
+	common.Must(r.Init(context.Background(), &Config{Rule: []*RoutingRule{
+		{
+			RuleTag:   "port",
+			TargetTag: &RoutingRule_Tag{Tag: "direct"},
+			Domain:    []*Domain{{Type: Domain_Domain, Value: "example.com"}},
+			PortList:  &net.PortList{Range: []*net.PortRange{net.SinglePortRange(80)}},
+		},
+		{
+			TargetTag: &RoutingRule_Tag{Tag: "proxy"},
+			Networks:  []net.Network{net.Network_TCP},
+		},
+	}}, nil))
+
+	explanation, err := r.Explain(withOutbound(&session.Outbound{Target: net.TCPDestination(net.DomainAddress("example.com"), 443)}))
+	common.Must(err)
+	if len(explanation.Traces) != 2 {
+		t.Fatal("traces: ", len(explanation.Traces))
+	}
+	if trace := explanation.Traces[0]; trace.Matched || trace.RuleTag != "port" || trace.RejectedBy != "port" {
+		t.Error("unexpected trace of the first rule: ", trace)
+	}
+	if explanation.Matched != explanation.Traces[1] || explanation.OutboundTag != "proxy" {
+		t.Error("unexpected match: ", explanation.Matched, ", ", explanation.OutboundTag)
+	}
+
+	explanation, err = r.Explain(withOutbound(&session.Outbound{Target: net.UDPDestination(net.DomainAddress("example.org"), 80)}))
+	common.Must(err)
+	if explanation.Matched != nil || explanation.Traces[0].RejectedBy != "domain" || explanation.Traces[1].RejectedBy != "network" {
+		t.Error("unexpected explanation for an unmatched context")
+	}
+}
+
+// fakeSelector selects outbounds whose tags are the selectors.
+type fakeSelector struct {
+	outbound.Manager
+}
+
+func (fakeSelector) Select(selectors []string) []string {
+	return selectors
+}
+
+func TestRuleStats(t *testing.T) {
+	// Last hits are recorded in seconds.
+	before := time.Now().Truncate(time.Second)
+	r := new(Router)
+	common.Must(r.Init(context.Background(), &Config{
+		Rule: []*RoutingRule{
+			{
+				RuleTag:   "domain",
+				TargetTag: &RoutingRule_Tag{Tag: "direct"},
+				Domain:    []*Domain{{Type: Domain_Domain, Value: "example.com"}},
+			},
+			{
+				TargetTag: &RoutingRule_BalancingTag{BalancingTag: "b"},
+				PortList:  &net.PortList{Range: []*net.PortRange{net.SinglePortRange(443)}},
+			},
+			{
+				TargetTag: &RoutingRule_Tag{Tag: "dead"},
+				PortList:  &net.PortList{Range: []*net.PortRange{net.SinglePortRange(22)}},
+			},
+		},
+		BalancingRule: []*BalancingRule{{Tag: "b", OutboundSelector: []string{"proxy"}}},
+	}, fakeSelector{}))
+
+	for i := 0; i < 3; i++ {
+		pickTag(r, net.TCPDestination(net.DomainAddress("www.example.com"), 80))
+	}
+	pickTag(r, net.TCPDestination(net.DomainAddress("www.example.org"), 443))
+	pickTag(r, net.TCPDestination(net.DomainAddress("www.example.org"), 80))
+
+	rules := r.ListRules()
+	if len(rules) != 3 {
+		t.Fatal("unexpected rules: ", rules)
+	}
+	for i, hits := range []uint64{3, 1, 0} {
+		if rules[i].Index != i || rules[i].Hits != hits {
+			t.Error("rule ", i, ": ", rules[i].Hits, " hits, want ", hits)
+		}
+	}
+	if rules[0].LastHit.Before(before) || !rules[2].LastHit.IsZero() {
+		t.Error("unexpected last hits: ", rules[0].LastHit, ", ", rules[2].LastHit)
+	}
+	if balancers := r.ListBalancers(); len(balancers) != 1 || balancers[0].Picks["proxy"] != 1 {
+		t.Error("unexpected balancer picks: ", balancers)
+	}
+
+	// Counters are kept when other rules change.
+	common.Must(r.RemoveRule("domain"))
+	if rules := r.ListRules(); rules[0].Hits != 1 {
+		t.Error("counter of rule is not kept: ", rules[0].Hits)
+	}
+}
+
+func BenchmarkPickRoute(b *testing.B) {
+	config := &Config{
+		Rule: []*RoutingRule{
+			{
+				TargetTag: &RoutingRule_Tag{Tag: "direct"},
+				Domain:    []*Domain{{Type: Domain_Domain, Value: "example.com"}},
+			},
+			{
+				TargetTag: &RoutingRule_Tag{Tag: "dead"},
+				PortList:  &net.PortList{Range: []*net.PortRange{net.SinglePortRange(22)}},
+			},
+			{
+				TargetTag: &RoutingRule_BalancingTag{BalancingTag: "b"},
+				PortList:  &net.PortList{Range: []*net.PortRange{net.SinglePortRange(443)}},
+			},
+		},
+		BalancingRule: []*BalancingRule{{Tag: "b", OutboundSelector: []string{"proxy"}}},
+	}
+	r := new(Router)
+	common.Must(r.Init(context.Background(), config, fakeSelector{}))
+	ctx := withOutbound(&session.Outbound{Target: net.TCPDestination(net.DomainAddress("www.example.org"), 443)})
+
+	// Conditions only matches the rules, as a baseline of the bookkeeping
+	// of PickRoute.
+	conds := make([]Condition, len(config.Rule))
+	for i, rule := range config.Rule {
+		cond, err := rule.BuildCondition()
+		common.Must(err)
+		conds[i] = cond
+	}
+	b.Run("Conditions", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, cond := range conds {
+				if cond.Apply(ctx) {
+					break
+				}
+			}
+		}
+	})
+	b.Run("PickRoute", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			common.Must2(r.PickRoute(ctx))
+		}
+	})
+	b.Run("PickRouteParallel", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				common.Must2(r.PickRoute(ctx))
+			}
+		})
+	})
+}
@@ -0,0 +1,242 @@
+package router
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/platform/filesystem"
+	"github.com/xtls/xray-core/features/routing"
+)
+
+// ruleSetCheckInterval is how often the router checks rule set files for
+// changes.
+const ruleSetCheckInterval = 10 * time.Second
+
+// RuleSet is the content of a rule set file.
+type RuleSet struct {
+	Domains []*Domain
+	CIDRs   []*CIDR
+}
+
+var ruleSetPrefixes = []struct {
+	prefix string
+	typ    Domain_Type
+}{
+	{"domain:", Domain_Domain},
+	{"full:", Domain_Full},
+	{"regexp:", Domain_Regex},
+	{"keyword:", Domain_Plain},
+}
+
+// ParseRuleSet parses a rule set file: one domain pattern or CIDR per line.
+// Domain patterns are prefixed with "domain:", "full:", "regexp:" or
+// "keyword:", like in routing rules; a domain without prefix matches the
+// domain and its subdomains.
+// Empty lines and lines starting with '#' or "//" are ignored.
+func ParseRuleSet(content []byte) (*RuleSet, error) {
+	rs := new(RuleSet)
+	for i, line := range bytes.Split(content, []byte{'\n'}) {
+		s := strings.TrimSpace(string(line))
+		if s == "" || strings.HasPrefix(s, "#") || strings.HasPrefix(s, "//") {
+			continue
+		}
+		if cidr := parseRuleSetCIDR(s); cidr != nil {
+			rs.CIDRs = append(rs.CIDRs, cidr)
+			continue
+		}
+		domain := &Domain{Type: Domain_Domain, Value: s}
+		for _, p := range ruleSetPrefixes {
+			if strings.HasPrefix(s, p.prefix) {
+				domain = &Domain{Type: p.typ, Value: strings.TrimSpace(s[len(p.prefix):])}
+				break
+			}
+		}
+		if domain.Type != Domain_Regex {
+			domain.Value = strings.ToLower(domain.Value)
+		}
+		if domain.Value == "" || strings.ContainsAny(domain.Value, " \t") {
+			return nil, newError("invalid rule at line ", i+1, ": ", s)
+		}
+		rs.Domains = append(rs.Domains, domain)
+	}
+	return rs, nil
+}
+
+func parseRuleSetCIDR(s string) *CIDR {
+	if _, network, err := net.ParseCIDR(s); err == nil {
+		ones, _ := network.Mask.Size()
+		return &CIDR{Ip: network.IP, Prefix: uint32(ones)}
+	}
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	return &CIDR{Ip: ip, Prefix: uint32(len(ip) * 8)}
+}
+
+// fileStat identifies the version of a file. Files that cannot be stat'ed
+// have a size of -1.
+type fileStat struct {
+	modTime time.Time
+	size    int64
+}
+
+// RuleSetCondition is a condition built from rule set files. It rebuilds the
+// condition when the files change, and swaps it in atomically.
+type RuleSetCondition struct {
+	name  string
+	files []string
+	build func(*RuleSet) (Condition, error)
+
+	access sync.Mutex
+	stats  []fileStat
+	// pending is the stats of changed files, which are loaded once they
+	// stop changing.
+	pending []fileStat
+	// failed is the stats of files that failed to load, which are not
+	// loaded again until they change.
+	failed  []fileStat
+	current atomic.Pointer[Condition]
+}
+
+// NewRuleSetCondition loads the rule set files, and builds the condition
+// with their content. name is the rule field the condition matches, such as
+// "domain" or "ip".
+func NewRuleSetCondition(name string, files []string, build func(*RuleSet) (Condition, error)) (*RuleSetCondition, error) {
+	c := &RuleSetCondition{
+		name:  name,
+		files: files,
+		build: build,
+	}
+	if _, err := c.Reload(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Apply implements Condition.
+func (c *RuleSetCondition) Apply(ctx routing.Context) bool {
+	return (*c.current.Load()).Apply(ctx)
+}
+
+// Reload rebuilds the condition if the files changed since the last load.
+// As files may be partially written, changed files are only loaded when
+// they are unchanged since the previous call. The current condition is kept
+// if the files fail to load, and the error is only returned once until the
+// files change again.
+func (c *RuleSetCondition) Reload() (bool, error) {
+	c.access.Lock()
+	defer c.access.Unlock()
+
+	stats := c.statFiles()
+	if equalFileStats(stats, c.stats) {
+		c.pending = nil
+		return false, nil
+	}
+	if c.stats != nil && !equalFileStats(stats, c.pending) {
+		c.pending = stats
+		return false, nil
+	}
+	if equalFileStats(stats, c.failed) {
+		return false, nil
+	}
+	reloaded, err := c.load(stats)
+	if err != nil {
+		c.failed = stats
+	}
+	return reloaded, err
+}
+
+func (c *RuleSetCondition) load(stats []fileStat) (bool, error) {
+
+	merged := new(RuleSet)
+	for _, file := range c.files {
+		content, err := filesystem.ReadFile(file)
+		if err != nil {
+			return false, newError("failed to read rule set ", file).Base(err)
+		}
+		rs, err := ParseRuleSet(content)
+		if err != nil {
+			return false, newError("failed to parse rule set ", file).Base(err)
+		}
+		merged.Domains = append(merged.Domains, rs.Domains...)
+		merged.CIDRs = append(merged.CIDRs, rs.CIDRs...)
+	}
+	cond, err := c.build(merged)
+	if err != nil {
+		return false, newError("failed to build rule set ", strings.Join(c.files, ", ")).Base(err)
+	}
+	c.current.Store(&cond)
+	c.stats = stats
+	c.pending = nil
+	c.failed = nil
+	return true, nil
+}
+
+func (c *RuleSetCondition) statFiles() []fileStat {
+	stats := make([]fileStat, len(c.files))
+	for i, file := range c.files {
+		stats[i] = fileStat{size: -1}
+		if info, err := os.Stat(file); err == nil {
+			stats[i] = fileStat{modTime: info.ModTime(), size: info.Size()}
+		}
+	}
+	return stats
+}
+
+func equalFileStats(a, b []fileStat) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// String implements fmt.Stringer.
+func (c *RuleSetCondition) String() string {
+	return c.name
+}
+
+// reloadRuleSets reloads the changed rule set files of rules.
+func reloadRuleSets(rules []*Rule) {
+	for _, rule := range rules {
+		reloadConditionRuleSets(rule.Condition)
+	}
+}
+
+func reloadConditionRuleSets(cond Condition) {
+	switch c := cond.(type) {
+	case *ConditionChan:
+		for _, cond := range *c {
+			reloadConditionRuleSets(cond)
+		}
+	case OrCondition:
+		for _, cond := range c {
+			reloadConditionRuleSets(cond)
+		}
+	case *NotCondition:
+		reloadConditionRuleSets(c.Condition)
+	case *DomainListMatcher:
+		if c.domains != nil {
+			reloadConditionRuleSets(c.domains)
+		}
+	case *RuleSetCondition:
+		if reloaded, err := c.Reload(); err != nil {
+			newError("failed to reload rule set").Base(err).AtWarning().WriteToLog()
+		} else if reloaded {
+			newError("reloaded rule set ", strings.Join(c.files, ", ")).AtInfo().WriteToLog()
+		}
+	}
+}
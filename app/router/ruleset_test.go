@@ -0,0 +1,107 @@
+package router_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/xtls/xray-core/app/router"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/session"
+)
+
+func TestParseRuleSet(t *testing.T) {
+	rs, err := ParseRuleSet([]byte(`
+# comment
+// another comment
+domain:Example.com
+full:www.google.com
+regexp:^A\.b$
+keyword:ads
+tracker
+10.0.0.0/8
+2001:db8::1
+`))
+	common.Must(err)
+	domains := []*Domain{
+		{Type: Domain_Domain, Value: "example.com"},
+		{Type: Domain_Full, Value: "www.google.com"},
+		{Type: Domain_Regex, Value: `^A\.b$`},
+		{Type: Domain_Plain, Value: "ads"},
+		{Type: Domain_Domain, Value: "tracker"},
+	}
+	if len(rs.Domains) != len(domains) {
+		t.Fatal("unexpected domains: ", rs.Domains)
+	}
+	for i, d := range domains {
+		if rs.Domains[i].Type != d.Type || rs.Domains[i].Value != d.Value {
+			t.Error("domain ", i, ": ", rs.Domains[i], ", want ", d)
+		}
+	}
+	if len(rs.CIDRs) != 2 || rs.CIDRs[0].Prefix != 8 || len(rs.CIDRs[0].Ip) != 4 || rs.CIDRs[1].Prefix != 128 {
+		t.Error("unexpected CIDRs: ", rs.CIDRs)
+	}
+
+	if _, err := ParseRuleSet([]byte("domain:\n")); err == nil {
+		t.Error("expected an error for an empty domain")
+	}
+}
+
+func TestRuleSetReload(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "list.txt")
+	common.Must(os.WriteFile(file, []byte("domain:example.com\n"), 0o644))
+
+	rule := &RoutingRule{
+		Domain:        []*Domain{{Type: Domain_Full, Value: "static.org"}},
+		DomainRuleset: []string{file},
+	}
+	cond, err := rule.BuildCondition()
+	common.Must(err)
+
+	match := func(domain string) bool {
+		return cond.Apply(withOutbound(&session.Outbound{Target: net.TCPDestination(net.DomainAddress(domain), 80)}))
+	}
+	if !match("www.example.com") || !match("static.org") || match("example.org") {
+		t.Error("unexpected matches of the initial rule set")
+	}
+
+	common.Must(os.WriteFile(file, []byte("domain:example.org\n# example.com is removed\n"), 0o644))
+	rs := (*cond.(*ConditionChan))[0].(OrCondition)[1].(*RuleSetCondition)
+	reloaded, err := rs.Reload()
+	common.Must(err)
+	if reloaded {
+		t.Error("rule set is reloaded before the file settles")
+	}
+	reloaded, err = rs.Reload()
+	common.Must(err)
+	if !reloaded {
+		t.Fatal("rule set is not reloaded")
+	}
+	if match("www.example.com") || !match("static.org") || !match("example.org") {
+		t.Error("unexpected matches of the reloaded rule set")
+	}
+
+	reloaded, err = rs.Reload()
+	common.Must(err)
+	if reloaded {
+		t.Error("rule set is reloaded without changes")
+	}
+
+	common.Must(os.WriteFile(file, []byte("domain:\n"), 0o644))
+	common.Must2(rs.Reload())
+	if _, err := rs.Reload(); err == nil {
+		t.Error("expected an error for an invalid rule set")
+	}
+	if _, err := rs.Reload(); err != nil {
+		t.Error("unchanged invalid rule set is reloaded again: ", err)
+	}
+	if !match("example.org") {
+		t.Error("rule set is not kept after a failed reload")
+	}
+
+	common.Must(os.WriteFile(file, []byte("domain:example.org\n10.0.0.0/8\n"), 0o644))
+	if _, err := rule.BuildCondition(); err == nil {
+		t.Error("expected an error for CIDRs in a domain rule set")
+	}
+}
@@ -27,6 +27,8 @@ type AccessMessage struct {
 	Reason interface{}
 	Email  string
 	Detour string
+	// TLSResumed is true if the inbound TLS connection resumed a session.
+	TLSResumed bool
 }
 
 func (m *AccessMessage) String() string {
@@ -53,6 +55,10 @@ func (m *AccessMessage) String() string {
 		builder.WriteString(m.Email)
 	}
 
+	if m.TLSResumed {
+		builder.WriteString(" tls: resumed")
+	}
+
 	return builder.String()
 }
 
@@ -0,0 +1,201 @@
+package mmdb
+
+import (
+	"encoding/binary"
+	"math"
+	"math/big"
+)
+
+// Types of the data section.
+const (
+	typeExtended  = 0
+	typePointer   = 1
+	typeString    = 2
+	typeDouble    = 3
+	typeBytes     = 4
+	typeUint16    = 5
+	typeUint32    = 6
+	typeMap       = 7
+	typeInt32     = 8
+	typeUint64    = 9
+	typeUint128   = 10
+	typeArray     = 11
+	typeContainer = 12
+	typeEndMarker = 13
+	typeBool      = 14
+	typeFloat     = 15
+)
+
+// decoder decodes values of a data section. Maps are decoded as
+// map[string]interface{}, arrays as []interface{}, unsigned integers as
+// uint64 or *big.Int, and int32 as int64.
+type decoder struct {
+	data []byte
+}
+
+// decode decodes the value at offset, and returns it with the offset after
+// it.
+func (d *decoder) decode(offset uint, depth int) (interface{}, uint, error) {
+	if depth > 64 {
+		return nil, 0, newError("data section is nested too deeply")
+	}
+	typ, size, offset, err := d.decodeControl(offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if typ == typePointer {
+		pointer, next, err := d.decodePointer(size, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		value, _, err := d.decode(pointer, depth+1)
+		return value, next, err
+	}
+
+	switch typ {
+	case typeMap:
+		m := make(map[string]interface{}, size)
+		for i := uint(0); i < size; i++ {
+			key, next, err := d.decode(offset, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			k, ok := key.(string)
+			if !ok {
+				return nil, 0, newError("map key is not a string")
+			}
+			value, next, err := d.decode(next, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			m[k] = value
+			offset = next
+		}
+		return m, offset, nil
+	case typeArray:
+		a := make([]interface{}, 0, size)
+		for i := uint(0); i < size; i++ {
+			value, next, err := d.decode(offset, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			a = append(a, value)
+			offset = next
+		}
+		return a, offset, nil
+	case typeBool:
+		return size != 0, offset, nil
+	case typeContainer, typeEndMarker:
+		return nil, 0, newError("unexpected data type ", typ)
+	}
+
+	end := offset + size
+	if end > uint(len(d.data)) || end < offset {
+		return nil, 0, newError("unexpected end of data section")
+	}
+	b := d.data[offset:end]
+	switch typ {
+	case typeString:
+		return string(b), end, nil
+	case typeBytes:
+		return append([]byte(nil), b...), end, nil
+	case typeDouble:
+		if size != 8 {
+			return nil, 0, newError("invalid size of double: ", size)
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), end, nil
+	case typeFloat:
+		if size != 4 {
+			return nil, 0, newError("invalid size of float: ", size)
+		}
+		return math.Float32frombits(binary.BigEndian.Uint32(b)), end, nil
+	case typeUint16, typeUint32, typeUint64:
+		if size > 8 {
+			return nil, 0, newError("invalid size of unsigned integer: ", size)
+		}
+		var v uint64
+		for _, c := range b {
+			v = v<<8 | uint64(c)
+		}
+		return v, end, nil
+	case typeInt32:
+		if size > 4 {
+			return nil, 0, newError("invalid size of int32: ", size)
+		}
+		var v uint32
+		for _, c := range b {
+			v = v<<8 | uint32(c)
+		}
+		return int64(int32(v)), end, nil
+	case typeUint128:
+		if size > 16 {
+			return nil, 0, newError("invalid size of uint128: ", size)
+		}
+		return new(big.Int).SetBytes(b), end, nil
+	}
+	return nil, 0, newError("unknown data type ", typ)
+}
+
+// decodeControl decodes the control byte at offset, and returns the type and
+// size of the value, and the offset of its payload.
+func (d *decoder) decodeControl(offset uint) (int, uint, uint, error) {
+	if offset >= uint(len(d.data)) {
+		return 0, 0, 0, newError("unexpected end of data section")
+	}
+	control := d.data[offset]
+	offset++
+	typ := int(control >> 5)
+	if typ == typeExtended {
+		if offset >= uint(len(d.data)) {
+			return 0, 0, 0, newError("unexpected end of data section")
+		}
+		typ = 7 + int(d.data[offset])
+		offset++
+	}
+	size := uint(control & 0x1f)
+	if typ == typePointer || size < 29 {
+		return typ, size, offset, nil
+	}
+
+	n := size - 28
+	if offset+n > uint(len(d.data)) {
+		return 0, 0, 0, newError("unexpected end of data section")
+	}
+	var v uint
+	for _, c := range d.data[offset : offset+n] {
+		v = v<<8 | uint(c)
+	}
+	switch size {
+	case 29:
+		size = 29 + v
+	case 30:
+		size = 285 + v
+	default:
+		size = 65821 + v
+	}
+	return typ, size, offset + n, nil
+}
+
+// decodePointer decodes a pointer with the size bits of its control byte,
+// and returns the offset it points to and the offset after it.
+func (d *decoder) decodePointer(size uint, offset uint) (uint, uint, error) {
+	n := (size>>3)&0x3 + 1
+	if offset+n > uint(len(d.data)) {
+		return 0, 0, newError("unexpected end of data section")
+	}
+	var v uint
+	if n != 4 {
+		v = size & 0x7
+	}
+	for _, c := range d.data[offset : offset+n] {
+		v = v<<8 | uint(c)
+	}
+	switch n {
+	case 2:
+		v += 2048
+	case 3:
+		v += 526336
+	}
+	return v, offset + n, nil
+}
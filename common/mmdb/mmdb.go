@@ -0,0 +1,237 @@
+// Package mmdb reads MaxMind DB files, such as the GeoIP2, GeoLite2 and
+// DB-IP country and ASN databases.
+package mmdb
+
+//go:generate go run github.com/xtls/xray-core/common/errors/errorgen
+
+import (
+	"bytes"
+	"net/netip"
+)
+
+var metadataMarker = []byte("\xab\xcd\xefMaxMind.com")
+
+// Metadata of a database.
+type Metadata struct {
+	NodeCount    uint
+	RecordSize   uint
+	IPVersion    uint
+	DatabaseType string
+}
+
+// Reader reads a database in memory.
+type Reader struct {
+	Metadata Metadata
+
+	tree    []byte
+	data    decoder
+	ipv4Len int
+	// ipv4Start is the node of ::/96 in IPv6 databases, or the root in IPv4
+	// databases.
+	ipv4Start uint
+}
+
+// New creates a Reader of the database content b.
+func New(b []byte) (*Reader, error) {
+	i := bytes.LastIndex(b, metadataMarker)
+	if i < 0 {
+		return nil, newError("not a MaxMind DB file")
+	}
+	metadataDecoder := decoder{data: b[i+len(metadataMarker):]}
+	value, _, err := metadataDecoder.decode(0, 0)
+	if err != nil {
+		return nil, newError("failed to decode metadata").Base(err)
+	}
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, newError("invalid metadata")
+	}
+	r := &Reader{
+		Metadata: Metadata{
+			NodeCount:  uintValue(m["node_count"]),
+			RecordSize: uintValue(m["record_size"]),
+			IPVersion:  uintValue(m["ip_version"]),
+		},
+	}
+	r.Metadata.DatabaseType, _ = m["database_type"].(string)
+
+	switch r.Metadata.RecordSize {
+	case 24, 28, 32:
+	default:
+		return nil, newError("unsupported record size: ", r.Metadata.RecordSize)
+	}
+	treeSize := r.Metadata.NodeCount * r.Metadata.RecordSize / 4
+	if treeSize+16 > uint(i) {
+		return nil, newError("search tree is larger than the file")
+	}
+	r.tree = b[:treeSize]
+	r.data = decoder{data: b[treeSize+16 : i]}
+
+	switch r.Metadata.IPVersion {
+	case 4:
+		r.ipv4Len = 32
+	case 6:
+		node := uint(0)
+		for i := 0; i < 96 && node < r.Metadata.NodeCount; i++ {
+			node = r.record(node, 0)
+		}
+		r.ipv4Start = node
+		r.ipv4Len = 128
+	default:
+		return nil, newError("unsupported IP version: ", r.Metadata.IPVersion)
+	}
+	return r, nil
+}
+
+func uintValue(v interface{}) uint {
+	n, _ := v.(uint64)
+	return uint(n)
+}
+
+// record returns the left (bit 0) or right (bit 1) record of node.
+func (r *Reader) record(node uint, bit int) uint {
+	switch r.Metadata.RecordSize {
+	case 24:
+		b := r.tree[node*6+uint(bit)*3:]
+		return uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+	case 28:
+		b := r.tree[node*7:]
+		if bit == 0 {
+			return uint(b[3]&0xf0)<<20 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+		}
+		return uint(b[3]&0x0f)<<24 | uint(b[4])<<16 | uint(b[5])<<8 | uint(b[6])
+	default:
+		b := r.tree[node*8+uint(bit)*4:]
+		return uint(b[0])<<24 | uint(b[1])<<16 | uint(b[2])<<8 | uint(b[3])
+	}
+}
+
+// resolve returns the offset in the data section of a record pointing to
+// data.
+func (r *Reader) resolve(record uint) (uint, error) {
+	offset := record - r.Metadata.NodeCount - 16
+	if record < r.Metadata.NodeCount+16 || offset >= uint(len(r.data.data)) {
+		return 0, newError("invalid data pointer in search tree")
+	}
+	return offset, nil
+}
+
+// Lookup returns the data of the network containing ip, or nil if the
+// network has no data.
+func (r *Reader) Lookup(ip netip.Addr) (interface{}, error) {
+	node := uint(0)
+	var bits []byte
+	if ip.Is4() || ip.Is4In6() {
+		node = r.ipv4Start
+		a := ip.Unmap().As4()
+		bits = a[:]
+	} else {
+		if r.ipv4Len == 32 {
+			return nil, newError("IPv6 address in an IPv4 database: ", ip)
+		}
+		a := ip.As16()
+		bits = a[:]
+	}
+	for i := 0; i < len(bits)*8 && node < r.Metadata.NodeCount; i++ {
+		node = r.record(node, int(bits[i/8]>>(7-i%8)&1))
+	}
+	if node == r.Metadata.NodeCount {
+		return nil, nil
+	}
+	if node < r.Metadata.NodeCount {
+		return nil, newError("invalid search tree")
+	}
+	offset, err := r.resolve(node)
+	if err != nil {
+		return nil, err
+	}
+	value, _, err := r.data.decode(offset, 0)
+	return value, err
+}
+
+// Select returns the networks whose data match. The data of each record is
+// decoded once, as many networks share the same data. IPv4 networks of IPv6
+// databases are returned as IPv4 prefixes.
+func (r *Reader) Select(match func(data interface{}) bool) ([]netip.Prefix, error) {
+	matched := make(map[uint]bool)
+	var prefixes []netip.Prefix
+
+	var walk func(node uint, ip [16]byte, depth int) error
+	walk = func(node uint, ip [16]byte, depth int) error {
+		if depth > r.ipv4Len {
+			return newError("invalid search tree")
+		}
+		if node > r.Metadata.NodeCount {
+			offset, err := r.resolve(node)
+			if err != nil {
+				return err
+			}
+			m, found := matched[offset]
+			if !found {
+				data, _, err := r.data.decode(offset, 0)
+				if err != nil {
+					return err
+				}
+				m = match(data)
+				matched[offset] = m
+			}
+			if m {
+				prefixes = append(prefixes, r.prefix(ip, depth))
+			}
+			return nil
+		}
+		if node == r.Metadata.NodeCount {
+			return nil
+		}
+		// IPv6 databases alias networks such as ::ffff:0:0/96 and 2002::/16
+		// to the IPv4 networks at ::/96. Only walk them at ::/96.
+		if r.ipv4Len == 128 && node == r.ipv4Start && ip != [16]byte{} {
+			return nil
+		}
+		for bit := 0; bit < 2; bit++ {
+			child := ip
+			if bit == 1 {
+				child[depth/8] |= 0x80 >> (depth % 8)
+			}
+			if err := walk(r.record(node, bit), child, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := walk(0, [16]byte{}, 0); err != nil {
+		return nil, err
+	}
+	return prefixes, nil
+}
+
+func (r *Reader) prefix(ip [16]byte, depth int) netip.Prefix {
+	if r.ipv4Len == 32 {
+		return netip.PrefixFrom(netip.AddrFrom4([4]byte(ip[:4])), depth)
+	}
+	if depth >= 96 && bytes.Equal(ip[:12], make([]byte, 12)) {
+		return netip.PrefixFrom(netip.AddrFrom4([4]byte(ip[12:])), depth-96)
+	}
+	return netip.PrefixFrom(netip.AddrFrom16(ip), depth)
+}
+
+// CountryCode returns the ISO code of the country in data of a country or
+// city database, or else of the registered country.
+func CountryCode(data interface{}) string {
+	m, _ := data.(map[string]interface{})
+	for _, key := range []string{"country", "registered_country"} {
+		if country, ok := m[key].(map[string]interface{}); ok {
+			if code, ok := country["iso_code"].(string); ok && code != "" {
+				return code
+			}
+		}
+	}
+	return ""
+}
+
+// ASN returns the autonomous system number in data of an ASN database.
+func ASN(data interface{}) uint {
+	m, _ := data.(map[string]interface{})
+	return uintValue(m["autonomous_system_number"])
+}
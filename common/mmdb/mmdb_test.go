@@ -0,0 +1,281 @@
+package mmdb_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net/netip"
+	"sort"
+	"testing"
+
+	"github.com/xtls/xray-core/common"
+	. "github.com/xtls/xray-core/common/mmdb"
+)
+
+// pointer is a value encoded as a pointer to an offset of the data section.
+type pointer uint
+
+func encodeControl(buf *bytes.Buffer, typ int, size int) {
+	var sizeBits byte
+	var ext []byte
+	switch {
+	case size < 29:
+		sizeBits = byte(size)
+	case size < 285:
+		sizeBits, ext = 29, []byte{byte(size - 29)}
+	case size < 65821:
+		sizeBits, ext = 30, binary.BigEndian.AppendUint16(nil, uint16(size-285))
+	default:
+		sizeBits, ext = 31, binary.BigEndian.AppendUint32(nil, uint32(size-65821))[1:]
+	}
+	if typ <= 7 {
+		buf.WriteByte(byte(typ<<5) | sizeBits)
+	} else {
+		buf.WriteByte(sizeBits)
+		buf.WriteByte(byte(typ - 7))
+	}
+	buf.Write(ext)
+}
+
+func encodeValue(buf *bytes.Buffer, v interface{}) {
+	switch v := v.(type) {
+	case pointer:
+		buf.WriteByte(1<<5 | byte(v>>8))
+		buf.WriteByte(byte(v))
+	case string:
+		encodeControl(buf, 2, len(v))
+		buf.WriteString(v)
+	case uint32:
+		b := bytes.TrimLeft(binary.BigEndian.AppendUint32(nil, v), "\x00")
+		encodeControl(buf, 6, len(b))
+		buf.Write(b)
+	case uint16:
+		b := bytes.TrimLeft(binary.BigEndian.AppendUint16(nil, v), "\x00")
+		encodeControl(buf, 5, len(b))
+		buf.Write(b)
+	case bool:
+		size := 0
+		if v {
+			size = 1
+		}
+		encodeControl(buf, 14, size)
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		encodeControl(buf, 7, len(keys))
+		for _, key := range keys {
+			encodeValue(buf, key)
+			encodeValue(buf, v[key])
+		}
+	default:
+		panic("unsupported value")
+	}
+}
+
+type testNode struct {
+	// children are *testNode, data offsets as int, or nil.
+	children [2]interface{}
+}
+
+type testNetwork struct {
+	prefix string
+	data   interface{}
+}
+
+// buildDatabase generates a database of the networks. Shared values are
+// stored at the beginning of the data section, so that data can point to
+// them.
+func buildDatabase(ipVersion int, recordSize int, shared []interface{}, networks []testNetwork) []byte {
+	var data bytes.Buffer
+	for _, v := range shared {
+		encodeValue(&data, v)
+	}
+
+	root := new(testNode)
+	insert := func(ip [16]byte, bits int, child interface{}) {
+		node := root
+		for i := 0; i < bits-1; i++ {
+			bit := ip[i/8] >> (7 - i%8) & 1
+			next, ok := node.children[bit].(*testNode)
+			if !ok {
+				next = new(testNode)
+				node.children[bit] = next
+			}
+			node = next
+		}
+		node.children[ip[(bits-1)/8]>>(7-(bits-1)%8)&1] = child
+	}
+	for _, network := range networks {
+		prefix := netip.MustParsePrefix(network.prefix)
+		offset := data.Len()
+		encodeValue(&data, network.data)
+		switch {
+		case ipVersion == 4:
+			var ip [16]byte
+			copy(ip[:], prefix.Addr().AsSlice())
+			insert(ip, prefix.Bits(), offset)
+		case prefix.Addr().Is4():
+			var ip [16]byte
+			copy(ip[12:], prefix.Addr().AsSlice())
+			insert(ip, prefix.Bits()+96, offset)
+		default:
+			insert(prefix.Addr().As16(), prefix.Bits(), offset)
+		}
+	}
+	if ipVersion == 6 {
+		// Alias ::ffff:0:0/96 to the IPv4 networks at ::/96.
+		ipv4 := root
+		for i := 0; i < 96; i++ {
+			ipv4 = ipv4.children[0].(*testNode)
+		}
+		insert(netip.MustParseAddr("::ffff:0:0").As16(), 96, ipv4)
+	}
+
+	index := make(map[*testNode]int)
+	var nodes []*testNode
+	for queue := []*testNode{root}; len(queue) > 0; queue = queue[1:] {
+		node := queue[0]
+		if _, found := index[node]; found {
+			continue
+		}
+		index[node] = len(nodes)
+		nodes = append(nodes, node)
+		for _, child := range node.children {
+			if child, ok := child.(*testNode); ok {
+				queue = append(queue, child)
+			}
+		}
+	}
+
+	var tree bytes.Buffer
+	for _, node := range nodes {
+		var records [2]uint32
+		for i, child := range node.children {
+			switch child := child.(type) {
+			case *testNode:
+				records[i] = uint32(index[child])
+			case int:
+				records[i] = uint32(len(nodes) + 16 + child)
+			default:
+				records[i] = uint32(len(nodes))
+			}
+		}
+		switch recordSize {
+		case 24:
+			tree.Write(binary.BigEndian.AppendUint32(nil, records[0])[1:])
+			tree.Write(binary.BigEndian.AppendUint32(nil, records[1])[1:])
+		case 28:
+			tree.Write(binary.BigEndian.AppendUint32(nil, records[0])[1:])
+			tree.WriteByte(byte(records[0]>>20&0xf0 | records[1]>>24&0x0f))
+			tree.Write(binary.BigEndian.AppendUint32(nil, records[1])[1:])
+		case 32:
+			tree.Write(binary.BigEndian.AppendUint32(nil, records[0]))
+			tree.Write(binary.BigEndian.AppendUint32(nil, records[1]))
+		}
+	}
+
+	var db bytes.Buffer
+	db.Write(tree.Bytes())
+	db.Write(make([]byte, 16))
+	db.Write(data.Bytes())
+	db.WriteString("\xab\xcd\xefMaxMind.com")
+	encodeValue(&db, map[string]interface{}{
+		"node_count":                  uint32(len(nodes)),
+		"record_size":                 uint16(recordSize),
+		"ip_version":                  uint16(ipVersion),
+		"database_type":               "Test",
+		"binary_format_major_version": uint16(2),
+	})
+	return db.Bytes()
+}
+
+func TestCountryDatabase(t *testing.T) {
+	for _, recordSize := range []int{24, 28, 32} {
+		db := buildDatabase(6, recordSize, []interface{}{
+			map[string]interface{}{"iso_code": "US"},
+		}, []testNetwork{
+			{"1.0.0.0/24", map[string]interface{}{"country": pointer(0)}},
+			{"8.8.8.0/24", map[string]interface{}{"country": map[string]interface{}{"iso_code": "US"}, "is_anycast": true}},
+			{"9.9.9.0/24", map[string]interface{}{"registered_country": map[string]interface{}{"iso_code": "CH"}}},
+			{"2001:db8::/32", map[string]interface{}{"country": map[string]interface{}{"iso_code": "CN"}}},
+		})
+		reader, err := New(db)
+		common.Must(err)
+		if reader.Metadata.RecordSize != uint(recordSize) || reader.Metadata.DatabaseType != "Test" {
+			t.Error("unexpected metadata: ", reader.Metadata)
+		}
+
+		for ip, code := range map[string]string{
+			"1.0.0.1":          "US",
+			"::ffff:1.0.0.1":   "US",
+			"8.8.8.8":          "US",
+			"9.9.9.9":          "CH",
+			"2001:db8::1":      "CN",
+			"3.3.3.3":          "",
+			"2001:db9::1":      "",
+			"::ffff:127.0.0.1": "",
+		} {
+			data, err := reader.Lookup(netip.MustParseAddr(ip))
+			common.Must(err)
+			if c := CountryCode(data); c != code {
+				t.Error("record size ", recordSize, ", country of ", ip, ": ", c, ", want ", code)
+			}
+		}
+
+		prefixes, err := reader.Select(func(data interface{}) bool {
+			return CountryCode(data) == "US"
+		})
+		common.Must(err)
+		if len(prefixes) != 2 || prefixes[0].String() != "1.0.0.0/24" || prefixes[1].String() != "8.8.8.0/24" {
+			t.Error("record size ", recordSize, ", US networks: ", prefixes)
+		}
+	}
+}
+
+func TestASNDatabase(t *testing.T) {
+	db := buildDatabase(4, 24, nil, []testNetwork{
+		{"1.1.1.0/24", map[string]interface{}{"autonomous_system_number": uint32(13335), "autonomous_system_organization": "CLOUDFLARENET"}},
+		{"1.0.0.0/24", map[string]interface{}{"autonomous_system_number": uint32(13335), "autonomous_system_organization": "CLOUDFLARENET"}},
+		{"8.8.8.0/24", map[string]interface{}{"autonomous_system_number": uint32(15169), "autonomous_system_organization": "GOOGLE"}},
+	})
+	reader, err := New(db)
+	common.Must(err)
+
+	data, err := reader.Lookup(netip.MustParseAddr("8.8.4.4"))
+	common.Must(err)
+	if data != nil {
+		t.Error("unexpected data of 8.8.4.4: ", data)
+	}
+	data, err = reader.Lookup(netip.MustParseAddr("8.8.8.8"))
+	common.Must(err)
+	if ASN(data) != 15169 || data.(map[string]interface{})["autonomous_system_organization"] != "GOOGLE" {
+		t.Error("unexpected data of 8.8.8.8: ", data)
+	}
+	if _, err := reader.Lookup(netip.MustParseAddr("2001:db8::1")); err == nil {
+		t.Error("expected an error for IPv6 in an IPv4 database")
+	}
+
+	prefixes, err := reader.Select(func(data interface{}) bool {
+		return ASN(data) == 13335
+	})
+	common.Must(err)
+	if len(prefixes) != 2 || prefixes[0].String() != "1.0.0.0/24" || prefixes[1].String() != "1.1.1.0/24" {
+		t.Error("networks of AS13335: ", prefixes)
+	}
+}
+
+func TestInvalidDatabase(t *testing.T) {
+	if _, err := New([]byte("not a database")); err == nil {
+		t.Error("expected an error for a file without metadata")
+	}
+	db := buildDatabase(4, 24, nil, []testNetwork{
+		{"1.1.1.0/24", map[string]interface{}{"autonomous_system_number": uint32(13335)}},
+	})
+	// Cut the search tree and the data section.
+	i := bytes.Index(db, []byte("\xab\xcd\xefMaxMind.com"))
+	if _, err := New(db[i-8:]); err == nil {
+		t.Error("expected an error for a truncated search tree")
+	}
+}
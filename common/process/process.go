@@ -0,0 +1,24 @@
+// Package process finds the local processes of connections.
+package process
+
+//go:generate go run github.com/xtls/xray-core/common/errors/errorgen
+
+import (
+	"github.com/xtls/xray-core/common/net"
+)
+
+// Info is a local process.
+type Info struct {
+	PID  int
+	UID  uint32
+	Name string
+}
+
+// FindProcess returns the local process owning the socket whose local
+// address is source. It is only supported on Linux.
+func FindProcess(network net.Network, source net.Destination) (*Info, error) {
+	if !source.Address.Family().IsIP() {
+		return nil, newError("not an IP address: ", source.Address)
+	}
+	return findProcess(network, source.Address.IP(), source.Port)
+}
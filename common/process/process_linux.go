@@ -0,0 +1,301 @@
+//go:build linux
+
+package process
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	gonet "net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/xtls/xray-core/common/net"
+)
+
+// socketOwner is a file descriptor of a socket.
+type socketOwner struct {
+	pid int
+	fd  string
+}
+
+// maxMissingSockets bounds the number of sockets remembered as not found.
+const maxMissingSockets = 4096
+
+// socketCache maps socket inodes to the processes owning them. It is rebuilt
+// by scanning /proc/*/fd when a socket is not found. Concurrent lookups
+// share scans, and sockets not found by a scan, such as the sockets of other
+// users, are not looked up again.
+type socketCache struct {
+	sync.Mutex
+	owners  map[uint64]socketOwner
+	missing map[uint64]struct{}
+	// started and done count the scans, which run one at a time. scanning is
+	// closed when the running scan is done.
+	started  uint64
+	done     uint64
+	scanning chan struct{}
+}
+
+var globalSocketCache socketCache
+
+func findProcess(network net.Network, ip net.IP, port net.Port) (*Info, error) {
+	var tables []string
+	switch network {
+	case net.Network_TCP:
+		tables = []string{"/proc/net/tcp", "/proc/net/tcp6"}
+	case net.Network_UDP:
+		tables = []string{"/proc/net/udp", "/proc/net/udp6"}
+	default:
+		return nil, newError("unsupported network: ", network)
+	}
+
+	// UDP sockets bound to the unspecified address send from local addresses
+	// only. Sources on other hosts, such as the clients of a transparent
+	// proxy, are not theirs.
+	unspecified := network == net.Network_UDP && isLocalIP(ip)
+
+	var inode uint64
+	var uid uint32
+	found := false
+	for _, table := range tables {
+		var err error
+		inode, uid, found, err = findSocket(table, ip, port, unspecified)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			break
+		}
+	}
+	if !found {
+		return nil, newError("no local socket of ", ip, ":", port)
+	}
+
+	info := &Info{UID: uid}
+	if inode == 0 {
+		// Sockets closed by their processes have no inode.
+		return info, nil
+	}
+	pid, found := globalSocketCache.find(inode)
+	if !found {
+		return info, nil
+	}
+	info.PID = pid
+	info.Name = processName(pid)
+	return info, nil
+}
+
+// isLocalIP reports whether ip is a loopback address or is assigned to a
+// local interface.
+func isLocalIP(ip net.IP) bool {
+	if ip.IsLoopback() {
+		return true
+	}
+	addrs, err := gonet.InterfaceAddrs()
+	if err != nil {
+		return false
+	}
+	for _, addr := range addrs {
+		if prefix, ok := addr.(*net.IPNet); ok && prefix.IP.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// findSocket finds the socket bound to ip and port in a table of /proc/net,
+// and returns its inode and user ID. If unspecified is true, sockets bound to
+// the unspecified address are matched if no socket is bound to ip, as UDP
+// sockets sending from any address are.
+func findSocket(table string, ip net.IP, port net.Port, unspecified bool) (uint64, uint32, bool, error) {
+	f, err := os.Open(table)
+	if os.IsNotExist(err) {
+		return 0, 0, false, nil
+	}
+	if err != nil {
+		return 0, 0, false, newError("failed to open ", table).Base(err)
+	}
+	defer f.Close()
+
+	var inode uint64
+	var uid uint32
+	found := false
+	scanner := bufio.NewScanner(f)
+	scanner.Scan() // Skip the header.
+	for scanner.Scan() {
+		// sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 {
+			continue
+		}
+		localIP, localPort, ok := parseAddress(fields[1])
+		if !ok || localPort != port {
+			continue
+		}
+		exact := localIP.Equal(ip)
+		if !exact && !(unspecified && localIP.IsUnspecified()) {
+			continue
+		}
+		u, err := strconv.ParseUint(fields[7], 10, 32)
+		if err != nil {
+			continue
+		}
+		n, err := strconv.ParseUint(fields[9], 10, 64)
+		if err != nil {
+			continue
+		}
+		inode, uid, found = n, uint32(u), true
+		if exact {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, 0, false, newError("failed to read ", table).Base(err)
+	}
+	return inode, uid, found, nil
+}
+
+// parseAddress parses an address of /proc/net tables, such as
+// "0100007F:1F90". IP addresses are printed as 32-bit words in host byte
+// order.
+func parseAddress(s string) (net.IP, net.Port, bool) {
+	host, port, found := strings.Cut(s, ":")
+	if !found {
+		return nil, 0, false
+	}
+	b, err := hex.DecodeString(host)
+	if err != nil || (len(b) != 4 && len(b) != 16) {
+		return nil, 0, false
+	}
+	ip := make(net.IP, len(b))
+	for i := 0; i < len(b); i += 4 {
+		binary.NativeEndian.PutUint32(ip[i:], binary.BigEndian.Uint32(b[i:]))
+	}
+	p, err := strconv.ParseUint(port, 16, 16)
+	if err != nil {
+		return nil, 0, false
+	}
+	return ip, net.Port(p), true
+}
+
+func (c *socketCache) find(inode uint64) (int, bool) {
+	c.Lock()
+	owner, found := c.owners[inode]
+	_, missing := c.missing[inode]
+	// Only scans started from now on can find a new socket.
+	next := c.started + 1
+	c.Unlock()
+
+	// The process may have closed the socket, and another socket may have
+	// reused the inode.
+	if found && owner.owns(inode) {
+		return owner.pid, true
+	}
+	if missing {
+		return 0, false
+	}
+
+	owner, found = c.scan(next)[inode]
+	if !found {
+		c.Lock()
+		if len(c.missing) >= maxMissingSockets || c.missing == nil {
+			c.missing = make(map[uint64]struct{})
+		}
+		c.missing[inode] = struct{}{}
+		c.Unlock()
+	}
+	return owner.pid, found
+}
+
+// scan returns the owners of sockets found by scan n or a later one. It runs
+// a scan if no such scan is running or done.
+func (c *socketCache) scan(n uint64) map[uint64]socketOwner {
+	for {
+		c.Lock()
+		if c.done >= n {
+			owners := c.owners
+			c.Unlock()
+			return owners
+		}
+		if scanning := c.scanning; scanning != nil {
+			c.Unlock()
+			<-scanning
+			continue
+		}
+		c.started++
+		scanning := make(chan struct{})
+		c.scanning = scanning
+		c.Unlock()
+
+		owners := scanSockets()
+
+		c.Lock()
+		c.owners = owners
+		c.done = c.started
+		c.scanning = nil
+		c.Unlock()
+		close(scanning)
+	}
+}
+
+// owns reports whether the file descriptor of o is still the socket inode.
+func (o socketOwner) owns(inode uint64) bool {
+	link, err := os.Readlink(o.fd)
+	return err == nil && link == socketLink(inode)
+}
+
+func socketLink(inode uint64) string {
+	return "socket:[" + strconv.FormatUint(inode, 10) + "]"
+}
+
+// scanSockets returns the owners of all sockets the current user can see.
+func scanSockets() map[uint64]socketOwner {
+	owners := make(map[uint64]socketOwner)
+	procs, err := os.ReadDir("/proc")
+	if err != nil {
+		return owners
+	}
+	for _, proc := range procs {
+		pid, err := strconv.Atoi(proc.Name())
+		if err != nil {
+			continue
+		}
+		dir := filepath.Join("/proc", proc.Name(), "fd")
+		fds, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			path := filepath.Join(dir, fd.Name())
+			link, err := os.Readlink(path)
+			if err != nil || !strings.HasPrefix(link, "socket:[") {
+				continue
+			}
+			inode, err := strconv.ParseUint(link[len("socket:["):len(link)-1], 10, 64)
+			if err != nil {
+				continue
+			}
+			owners[inode] = socketOwner{pid: pid, fd: path}
+		}
+	}
+	return owners
+}
+
+// processName returns the name of the executable of a process, or its
+// command name, which is truncated to 15 bytes, if the executable is not
+// readable.
+func processName(pid int) string {
+	dir := filepath.Join("/proc", strconv.Itoa(pid))
+	if exe, err := os.Readlink(filepath.Join(dir, "exe")); err == nil {
+		return filepath.Base(strings.TrimSuffix(exe, " (deleted)"))
+	}
+	comm, err := os.ReadFile(filepath.Join(dir, "comm"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(comm))
+}
@@ -0,0 +1,74 @@
+package process_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/net"
+	. "github.com/xtls/xray-core/common/process"
+)
+
+func checkProcess(t *testing.T, network net.Network, addr net.Addr) {
+	info, err := FindProcess(network, net.DestinationFromAddr(addr))
+	common.Must(err)
+	if info.PID != os.Getpid() || info.UID != uint32(os.Getuid()) {
+		t.Error("unexpected process of ", addr, ": ", info)
+	}
+	exe, err := os.Executable()
+	common.Must(err)
+	if info.Name != filepath.Base(exe) {
+		t.Error("unexpected process name: ", info.Name, ", want ", filepath.Base(exe))
+	}
+}
+
+func TestFindTCPProcess(t *testing.T) {
+	for _, address := range []string{"127.0.0.1:0", "[::1]:0"} {
+		listener, err := net.Listen("tcp", address)
+		if err != nil {
+			t.Skip("network unavailable: ", err)
+		}
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		common.Must(err)
+		checkProcess(t, net.Network_TCP, conn.LocalAddr())
+		conn.Close()
+		listener.Close()
+	}
+}
+
+func TestFindUDPProcess(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IP{127, 0, 0, 1}})
+	common.Must(err)
+	defer conn.Close()
+	checkProcess(t, net.Network_UDP, conn.LocalAddr())
+}
+
+func TestProcessNotFound(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	common.Must(err)
+	port := net.Port(listener.Addr().(*net.TCPAddr).Port)
+	listener.Close()
+	if _, err := FindProcess(net.Network_TCP, net.TCPDestination(net.LocalHostIP, port)); err == nil {
+		t.Error("expected an error for a closed socket")
+	}
+}
+
+func TestUnspecifiedAddress(t *testing.T) {
+	listener, err := net.Listen("tcp", "0.0.0.0:0")
+	common.Must(err)
+	defer listener.Close()
+	port := net.Port(listener.Addr().(*net.TCPAddr).Port)
+	if _, err := FindProcess(net.Network_TCP, net.TCPDestination(net.LocalHostIP, port)); err == nil {
+		t.Error("expected an error for a TCP listener on the unspecified address")
+	}
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.AnyIP.IP()})
+	common.Must(err)
+	defer conn.Close()
+	port = net.Port(conn.LocalAddr().(*net.UDPAddr).Port)
+	checkProcess(t, net.Network_UDP, &net.UDPAddr{IP: net.IP{127, 0, 0, 1}, Port: int(port)})
+	if _, err := FindProcess(net.Network_UDP, net.UDPDestination(net.ParseAddress("192.0.2.1"), port)); err == nil {
+		t.Error("expected an error for a UDP source on another host")
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package process
+
+import (
+	"github.com/xtls/xray-core/common/net"
+)
+
+func findProcess(network net.Network, ip net.IP, port net.Port) (*Info, error) {
+	return nil, newError("process lookup is not supported on this platform")
+}
@@ -1,15 +1,29 @@
 package tls
 
 import (
+	"crypto/md5"
+	"crypto/sha256"
 	"encoding/binary"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/xtls/xray-core/common"
 )
 
 type SniffHeader struct {
-	domain string
+	domain              string
+	version             uint16
+	cipherSuites        []uint16
+	extensions          []uint16
+	alpn                []string
+	supportedVersions   []uint16
+	supportedGroups     []uint16
+	pointFormats        []uint8
+	signatureAlgorithms []uint16
 }
 
 func (h *SniffHeader) Protocol() string {
@@ -20,6 +34,162 @@ func (h *SniffHeader) Domain() string {
 	return h.domain
 }
 
+// ALPN returns the application protocols offered by the client.
+func (h *SniffHeader) ALPN() []string {
+	return h.alpn
+}
+
+// Version returns the highest TLS version offered by the client.
+func (h *SniffHeader) Version() uint16 {
+	version := h.version
+	for _, v := range h.supportedVersions {
+		// Skip GREASE and the drafts of TLS 1.3.
+		if v <= 0x0304 && v > version {
+			version = v
+		}
+	}
+	return version
+}
+
+// JA3 returns the MD5 hash of the JA3 string of the ClientHello.
+// https://github.com/salesforce/ja3
+func (h *SniffHeader) JA3() string {
+	var b strings.Builder
+	b.WriteString(strconv.Itoa(int(h.version)))
+	for _, list := range [][]uint16{h.cipherSuites, h.extensions, h.supportedGroups} {
+		b.WriteByte(',')
+		writeDecimalList(&b, list)
+	}
+	b.WriteByte(',')
+	for i, f := range h.pointFormats {
+		if i > 0 {
+			b.WriteByte('-')
+		}
+		b.WriteString(strconv.Itoa(int(f)))
+	}
+	hash := md5.Sum([]byte(b.String()))
+	return hex.EncodeToString(hash[:])
+}
+
+// JA4 returns the JA4 fingerprint of the ClientHello, assuming it was sent over TCP.
+// https://github.com/FoxIO-LLC/ja4/blob/main/technical_details/JA4.md
+func (h *SniffHeader) JA4() string {
+	var b strings.Builder
+	b.WriteByte('t')
+	switch h.Version() {
+	case 0x0304:
+		b.WriteString("13")
+	case 0x0303:
+		b.WriteString("12")
+	case 0x0302:
+		b.WriteString("11")
+	case 0x0301:
+		b.WriteString("10")
+	case 0x0300:
+		b.WriteString("s3")
+	default:
+		b.WriteString("00")
+	}
+	if h.domain != "" {
+		b.WriteByte('d')
+	} else {
+		b.WriteByte('i')
+	}
+
+	ciphers := hexList(h.cipherSuites)
+	extensions := hexList(h.extensions)
+	fmt.Fprintf(&b, "%02d%02d", min(len(ciphers), 99), min(len(extensions), 99))
+	switch {
+	case len(h.alpn) == 0 || h.alpn[0] == "":
+		b.WriteString("00")
+	case isAlphanumeric(h.alpn[0][0]) && isAlphanumeric(h.alpn[0][len(h.alpn[0])-1]):
+		b.WriteByte(h.alpn[0][0])
+		b.WriteByte(h.alpn[0][len(h.alpn[0])-1])
+	default:
+		alpn := hex.EncodeToString([]byte(h.alpn[0]))
+		b.WriteByte(alpn[0])
+		b.WriteByte(alpn[len(alpn)-1])
+	}
+
+	sort.Strings(ciphers)
+	b.WriteByte('_')
+	b.WriteString(truncatedHash(strings.Join(ciphers, ","), len(ciphers) == 0))
+
+	sorted := extensions[:0:0]
+	for _, e := range extensions {
+		if e != "0000" && e != "0010" {
+			sorted = append(sorted, e)
+		}
+	}
+	sort.Strings(sorted)
+	extensionsString := strings.Join(sorted, ",")
+	if algorithms := hexList(h.signatureAlgorithms); len(algorithms) > 0 {
+		extensionsString += "_" + strings.Join(algorithms, ",")
+	}
+	b.WriteByte('_')
+	b.WriteString(truncatedHash(extensionsString, len(extensions) == 0))
+	return b.String()
+}
+
+// Attributes implements the attributes of sniffing results used for routing.
+func (h *SniffHeader) Attributes() map[string]string {
+	attributes := map[string]string{
+		"tls.ciphers": strings.Join(hexList(h.cipherSuites), ","),
+		"tls.ja3":     h.JA3(),
+		"tls.ja4":     h.JA4(),
+	}
+	if len(h.alpn) > 0 {
+		attributes["tls.alpn"] = strings.Join(h.alpn, ",")
+	}
+	switch version := h.Version(); version {
+	case 0x0304, 0x0303, 0x0302, 0x0301:
+		attributes["tls.version"] = "1." + strconv.Itoa(int(version-0x0301))
+	case 0x0300:
+		attributes["tls.version"] = "ssl3"
+	}
+	return attributes
+}
+
+func isGREASE(v uint16) bool {
+	return v&0x0f0f == 0x0a0a && v>>8 == v&0xff
+}
+
+func isAlphanumeric(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func writeDecimalList(b *strings.Builder, list []uint16) {
+	first := true
+	for _, v := range list {
+		if isGREASE(v) {
+			continue
+		}
+		if !first {
+			b.WriteByte('-')
+		}
+		first = false
+		b.WriteString(strconv.Itoa(int(v)))
+	}
+}
+
+func hexList(list []uint16) []string {
+	s := make([]string, 0, len(list))
+	for _, v := range list {
+		if !isGREASE(v) {
+			s = append(s, fmt.Sprintf("%04x", v))
+		}
+	}
+	return s
+}
+
+func truncatedHash(s string, empty bool) string {
+	if empty {
+		return "000000000000"
+	}
+	hash := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(hash[:6])
+}
+
 var (
 	errNotTLS         = errors.New("not TLS header")
 	errNotClientHello = errors.New("not client hello")
@@ -29,12 +199,35 @@ func IsValidTLSVersion(major, minor byte) bool {
 	return major == 3
 }
 
-// ReadClientHello returns server name (if any) from TLS client hello message.
+// readUint16List parses a list of uint16 prefixed by its length in bytes,
+// which takes prefixLen bytes.
+func readUint16List(data []byte, prefixLen int) ([]uint16, bool) {
+	if len(data) < prefixLen {
+		return nil, false
+	}
+	length := 0
+	for _, b := range data[:prefixLen] {
+		length = length<<8 | int(b)
+	}
+	data = data[prefixLen:]
+	if length%2 == 1 || len(data) < length {
+		return nil, false
+	}
+	list := make([]uint16, 0, length/2)
+	for i := 0; i < length; i += 2 {
+		list = append(list, binary.BigEndian.Uint16(data[i:]))
+	}
+	return list, true
+}
+
+// ReadClientHello returns server name (if any) from TLS client hello message,
+// along with the other parameters offered by the client.
 // https://github.com/golang/go/blob/master/src/crypto/tls/handshake_messages.go#L300
 func ReadClientHello(data []byte, h *SniffHeader) error {
 	if len(data) < 42 {
 		return common.ErrNoClue
 	}
+	h.version = binary.BigEndian.Uint16(data[4:6])
 	sessionIDLen := int(data[38])
 	if sessionIDLen > 32 || len(data) < 39+sessionIDLen {
 		return common.ErrNoClue
@@ -49,6 +242,7 @@ func ReadClientHello(data []byte, h *SniffHeader) error {
 	if cipherSuiteLen%2 == 1 || len(data) < 2+cipherSuiteLen {
 		return errNotClientHello
 	}
+	h.cipherSuites, _ = readUint16List(data, 2)
 	data = data[2+cipherSuiteLen:]
 	if len(data) < 1 {
 		return common.ErrNoClue
@@ -82,9 +276,11 @@ func ReadClientHello(data []byte, h *SniffHeader) error {
 		if len(data) < length {
 			return errNotClientHello
 		}
+		h.extensions = append(h.extensions, extension)
+		d := data[:length]
 
-		if extension == 0x00 { /* extensionServerName */
-			d := data[:length]
+		switch extension {
+		case 0x00: /* extensionServerName */
 			if len(d) < 2 {
 				return errNotClientHello
 			}
@@ -112,15 +308,37 @@ func ReadClientHello(data []byte, h *SniffHeader) error {
 						return errNotClientHello
 					}
 					h.domain = serverName
-					return nil
+					break
 				}
 				d = d[nameLen:]
 			}
+		case 0x0a: /* extensionSupportedCurves */
+			h.supportedGroups, _ = readUint16List(d, 2)
+		case 0x0b: /* extensionSupportedPoints */
+			if len(d) > 0 && len(d) >= 1+int(d[0]) {
+				h.pointFormats = append([]uint8(nil), d[1:1+int(d[0])]...)
+			}
+		case 0x0d: /* extensionSignatureAlgorithms */
+			h.signatureAlgorithms, _ = readUint16List(d, 2)
+		case 0x10: /* extensionALPN */
+			if len(d) < 2 {
+				break
+			}
+			d = d[2:]
+			for len(d) > 0 && len(d) >= 1+int(d[0]) {
+				h.alpn = append(h.alpn, string(d[1:1+int(d[0])]))
+				d = d[1+int(d[0]):]
+			}
+		case 0x2b: /* extensionSupportedVersions */
+			h.supportedVersions, _ = readUint16List(d, 1)
 		}
 		data = data[length:]
 	}
 
-	return errNotTLS
+	if h.domain == "" {
+		return errNotTLS
+	}
+	return nil
 }
 
 func SniffTLS(b []byte) (*SniffHeader, error) {
@@ -8,9 +8,10 @@ import (
 
 func TestTLSHeaders(t *testing.T) {
 	cases := []struct {
-		input  []byte
-		domain string
-		err    bool
+		input      []byte
+		domain     string
+		err        bool
+		attributes map[string]string
 	}{
 		{
 			input: []byte{
@@ -43,6 +44,13 @@ func TestTLSHeaders(t *testing.T) {
 			},
 			domain: "c.s-microsoft.com",
 			err:    false,
+			attributes: map[string]string{
+				"tls.alpn":    "h2,http/1.1",
+				"tls.version": "1.2",
+				"tls.ciphers": "c02b,c02f,c02c,c030,cca9,cca8,cc14,cc13,c013,c014,009c,009d,002f,0035,000a",
+				"tls.ja3":     "b8f81673c0e1d29908346f3bab892b9b",
+				"tls.ja4":     "t12d1510h2_f0daf39aad75_e69ac49eb88f",
+			},
 		},
 		{
 			input: []byte{
@@ -156,6 +164,12 @@ func TestTLSHeaders(t *testing.T) {
 			if header.Domain() != test.domain {
 				t.Error("expect domain ", test.domain, " but got ", header.Domain())
 			}
+			attributes := header.Attributes()
+			for key, value := range test.attributes {
+				if attributes[key] != value {
+					t.Error("expect ", key, " ", value, " but got ", attributes[key])
+				}
+			}
 		}
 	}
 }
@@ -1,6 +1,8 @@
 package strmatcher_test
 
 import (
+	"math/rand"
+	"runtime"
 	"strconv"
 	"testing"
 
@@ -60,3 +62,80 @@ func BenchmarkMarchGroup(b *testing.B) {
 		_ = g.Match("0.example.com")
 	}
 }
+
+// benchmarkDomains returns n random domains like those of large geosite
+// lists, with their types.
+func benchmarkDomains(n int) ([]string, []Type) {
+	r := rand.New(rand.NewSource(1))
+	const letters = "abcdefghijklmnopqrstuvwxyz0123456789-"
+	tlds := []string{"com", "net", "org", "io", "co.uk", "de", "jp"}
+	domains := make([]string, n)
+	types := make([]Type, n)
+	for i := range domains {
+		label := make([]byte, 4+r.Intn(12))
+		for j := range label {
+			label[j] = letters[r.Intn(len(letters)-1)]
+		}
+		domains[i] = string(label) + "." + tlds[r.Intn(len(tlds))]
+		if r.Intn(4) == 0 {
+			domains[i] = []string{"www", "api", "cdn", "static"}[r.Intn(4)] + "." + domains[i]
+		}
+		types[i] = Domain
+		if r.Intn(5) == 0 {
+			types[i] = Full
+		}
+	}
+	return domains, types
+}
+
+type domainGroup interface {
+	AddPattern(string, Type) (uint32, error)
+	Build()
+	Match(string) []uint32
+}
+
+func heapInUse() uint64 {
+	var stats runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&stats)
+	return stats.HeapInuse
+}
+
+// benchmarkDomainGroup reports the memory a group holds per pattern after
+// Build, and the time to match domains.
+func benchmarkDomainGroup(b *testing.B, newGroup func() domainGroup) {
+	domains, types := benchmarkDomains(100000)
+	before := heapInUse()
+	g := newGroup()
+	for i, domain := range domains {
+		_, err := g.AddPattern(domain, types[i])
+		common.Must(err)
+	}
+	g.Build()
+	size := heapInUse() - before
+
+	queries := make([]string, 1024)
+	for i := range queries {
+		if i%2 == 0 {
+			queries[i] = "www." + domains[i*97%len(domains)]
+		} else {
+			queries[i] = "www.example" + strconv.Itoa(i) + ".com"
+		}
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = g.Match(queries[i%len(queries)])
+	}
+	b.StopTimer()
+	runtime.KeepAlive(g)
+	b.ReportMetric(float64(size)/float64(len(domains)), "heap-B/pattern")
+}
+
+func BenchmarkMphMatcherGroup(b *testing.B) {
+	benchmarkDomainGroup(b, func() domainGroup { return NewMphMatcherGroup() })
+}
+
+func BenchmarkHybridMatcherGroup(b *testing.B) {
+	benchmarkDomainGroup(b, func() domainGroup { return NewHybridMatcherGroup() })
+}
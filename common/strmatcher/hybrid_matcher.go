@@ -0,0 +1,300 @@
+package strmatcher
+
+import (
+	"encoding/binary"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// hybridBlockSize is the number of keys in a front coded block of
+// HybridMatcherGroup. Larger blocks save memory, and cost more time to scan.
+const hybridBlockSize = 16
+
+// Flags of keys in HybridMatcherGroup.
+const (
+	hybridFull byte = 1 << iota
+	hybridDomain
+)
+
+// A HybridMatcherGroup matches domains with a much smaller memory footprint
+// than MphMatcherGroup, at the cost of a binary search per label of the
+// input. It is divided into three parts:
+//  1. `full` and `domain` patterns are reversed, sorted, and stored in blocks
+//     of front coded keys: each key only stores its suffix after the prefix
+//     it shares with the previous key, as sorted reversed domains share long
+//     prefixes such as "moc.elgoog.";
+//  2. `substr` patterns are matched by ac automaton;
+//  3. `regex` patterns are matched with the regex library.
+type HybridMatcherGroup struct {
+	ac            *ACAutomaton
+	otherMatchers []matcherEntry
+	count         uint32
+
+	// data is the blocks of keys. The first key of a block is stored as
+	// its length, bytes and flags, and the others as the length of the prefix
+	// shared with the previous key, the length of the rest, the rest and the
+	// flags. Lengths are uvarints.
+	data []byte
+	// blocks is the offsets of the blocks in data, and heads the first 8
+	// bytes of their first keys, which decide most comparisons of binary
+	// searches without reading data.
+	blocks []uint32
+	heads  []uint64
+	// patterns holds the full and domain patterns until Build.
+	patterns map[string]byte
+}
+
+func NewHybridMatcherGroup() *HybridMatcherGroup {
+	return &HybridMatcherGroup{
+		count:    1,
+		patterns: make(map[string]byte),
+	}
+}
+
+// AddPattern adds a pattern to HybridMatcherGroup
+func (g *HybridMatcherGroup) AddPattern(pattern string, t Type) (uint32, error) {
+	switch t {
+	case Substr:
+		if g.ac == nil {
+			g.ac = NewACAutomaton()
+		}
+		g.ac.Add(pattern, t)
+	case Full:
+		g.patterns[strings.ToLower(pattern)] |= hybridFull
+	case Domain:
+		g.patterns[strings.ToLower(pattern)] |= hybridDomain
+	case Regex:
+		r, err := regexp.Compile(pattern)
+		if err != nil {
+			return 0, err
+		}
+		g.otherMatchers = append(g.otherMatchers, matcherEntry{
+			m:  &regexMatcher{pattern: r},
+			id: g.count,
+		})
+	default:
+		panic("Unknown type")
+	}
+	return g.count, nil
+}
+
+// Build encodes the full and domain patterns, and builds the ac automaton.
+// Patterns can't be added after Build.
+func (g *HybridMatcherGroup) Build() {
+	if g.ac != nil {
+		g.ac.Build()
+	}
+
+	type entry struct {
+		key   string
+		flags byte
+	}
+	entries := make([]entry, 0, len(g.patterns))
+	for pattern, flags := range g.patterns {
+		entries = append(entries, entry{key: reverse(pattern), flags: flags})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].key < entries[j].key
+	})
+
+	var data []byte
+	blocks := make([]uint32, 0, (len(entries)+hybridBlockSize-1)/hybridBlockSize)
+	heads := make([]uint64, 0, cap(blocks))
+	prev := ""
+	for i, e := range entries {
+		if i%hybridBlockSize == 0 {
+			blocks = append(blocks, uint32(len(data)))
+			heads = append(heads, keyHead(e.key))
+			data = binary.AppendUvarint(data, uint64(len(e.key)))
+			data = append(data, e.key...)
+		} else {
+			shared := commonPrefixLen(prev, e.key)
+			data = binary.AppendUvarint(data, uint64(shared))
+			data = binary.AppendUvarint(data, uint64(len(e.key)-shared))
+			data = append(data, e.key[shared:]...)
+		}
+		data = append(data, e.flags)
+		prev = e.key
+	}
+	// Drop the unused capacity.
+	g.data = append([]byte(nil), data...)
+	g.blocks = blocks
+	g.heads = heads
+	g.patterns = nil
+}
+
+// hybridSeparator replaces dots in keys. It sorts before the other bytes of
+// domains, so that the keys of the subdomains of a domain directly follow
+// it.
+const hybridSeparator = 1
+
+// reverse returns the key of a pattern.
+func reverse(s string) string {
+	b := make([]byte, len(s))
+	for i := 0; i < len(s); i++ {
+		b[len(s)-1-i] = keyByte(s[i])
+	}
+	return string(b)
+}
+
+func keyByte(c byte) byte {
+	if c == '.' {
+		return hybridSeparator
+	}
+	return c
+}
+
+// keyHead returns the first 8 bytes of key as a big endian integer, padded
+// with zeros.
+func keyHead(key string) uint64 {
+	var h uint64
+	for i := 0; i < 8; i++ {
+		h <<= 8
+		if i < len(key) {
+			h |= uint64(key[i])
+		}
+	}
+	return h
+}
+
+// reversedHead returns the head of the key of s.
+func reversedHead(s string) uint64 {
+	var h uint64
+	for i := 0; i < 8; i++ {
+		h <<= 8
+		if i < len(s) {
+			h |= uint64(keyByte(s[len(s)-1-i]))
+		}
+	}
+	return h
+}
+
+func commonPrefixLen(a, b string) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return n
+}
+
+// compareReversed compares key with the key of s.
+func compareReversed(key []byte, s string) int {
+	for i := 0; i < len(key) && i < len(s); i++ {
+		c := keyByte(s[len(s)-1-i])
+		switch {
+		case key[i] < c:
+			return -1
+		case key[i] > c:
+			return 1
+		}
+	}
+	switch {
+	case len(key) < len(s):
+		return -1
+	case len(key) > len(s):
+		return 1
+	}
+	return 0
+}
+
+// isSubdomainKey reports whether key is the key of a subdomain of s.
+func isSubdomainKey(key []byte, s string) bool {
+	return len(key) > len(s) && key[len(s)] == hybridSeparator && compareReversed(key[:len(s)], s) == 0
+}
+
+func readUvarint(b []byte) (int, int) {
+	if b[0] < 0x80 {
+		return int(b[0]), 1
+	}
+	v, n := binary.Uvarint(b)
+	return int(v), n
+}
+
+// blockHead returns the first key of block i, and the offset after it.
+func (g *HybridMatcherGroup) blockHead(i int) ([]byte, int) {
+	n, l := readUvarint(g.data[g.blocks[i]:])
+	offset := int(g.blocks[i]) + l
+	return g.data[offset : offset+n], offset + n
+}
+
+// lookup finds the key of s in the blocks from block from. It returns the
+// flags of the key, or 0 if s is not a key, whether there are keys of
+// subdomains of s, and the block of the key.
+func (g *HybridMatcherGroup) lookup(s string, from int) (byte, bool, int) {
+	// Find the last block whose first key is not greater than s.
+	h := reversedHead(s)
+	block := from + sort.Search(len(g.blocks)-from, func(i int) bool {
+		if g.heads[from+i] != h {
+			return g.heads[from+i] > h
+		}
+		head, _ := g.blockHead(from + i)
+		return compareReversed(head, s) > 0
+	}) - 1
+	if block < 0 {
+		head, _ := g.blockHead(0)
+		return 0, isSubdomainKey(head, s), 0
+	}
+
+	var buf [256]byte
+	head, offset := g.blockHead(block)
+	key := append(buf[:0], head...)
+	var flags byte
+	for next := block + 1; ; {
+		switch compareReversed(key, s) {
+		case 0:
+			flags = g.data[offset]
+		case 1:
+			return flags, isSubdomainKey(key, s), block
+		}
+		offset++ // Skip the flags.
+		if offset == len(g.data) {
+			return flags, false, block
+		}
+		if next < len(g.blocks) && offset == int(g.blocks[next]) {
+			// Continue to the next block, to find keys of subdomains.
+			head, offset = g.blockHead(next)
+			key = append(key[:0], head...)
+			block, next = next, next+1
+			continue
+		}
+		shared, l := readUvarint(g.data[offset:])
+		offset += l
+		n, l := readUvarint(g.data[offset:])
+		offset += l
+		key = append(key[:shared], g.data[offset:offset+n]...)
+		offset += n
+	}
+}
+
+// Match implements IndexMatcher.Match.
+func (g *HybridMatcherGroup) Match(pattern string) []uint32 {
+	if len(g.blocks) > 0 {
+		// Look up the suffixes of pattern from its top-level domain. Their
+		// keys are in ascending order, and a suffix without keys of
+		// subdomains ends the search.
+		block := 0
+		for i := len(pattern) - 1; i >= 0; i-- {
+			if i > 0 && pattern[i-1] != '.' {
+				continue
+			}
+			flags, subdomains, b := g.lookup(pattern[i:], block)
+			if flags&hybridDomain != 0 || (i == 0 && flags&hybridFull != 0) {
+				return []uint32{1}
+			}
+			if !subdomains {
+				break
+			}
+			block = b
+		}
+	}
+	if g.ac != nil && g.ac.Match(pattern) {
+		return []uint32{1}
+	}
+	for _, e := range g.otherMatchers {
+		if e.m.Match(pattern) {
+			return []uint32{e.id}
+		}
+	}
+	return nil
+}
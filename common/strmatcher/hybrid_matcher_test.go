@@ -0,0 +1,94 @@
+package strmatcher_test
+
+import (
+	"math/rand"
+	"strconv"
+	"testing"
+
+	"github.com/xtls/xray-core/common"
+	. "github.com/xtls/xray-core/common/strmatcher"
+)
+
+func TestHybridMatcherGroup(t *testing.T) {
+	g := NewHybridMatcherGroup()
+	for _, rule := range []struct {
+		Type    Type
+		Pattern string
+	}{
+		{Domain, "Example.com"},
+		{Full, "google.com"},
+		{Domain, "a.b.com"},
+		{Full, "x.y.com"},
+		{Domain, "x.y.com"},
+		{Substr, "ads"},
+		{Regex, `^\d+\.test$`},
+	} {
+		_, err := g.AddPattern(rule.Pattern, rule.Type)
+		common.Must(err)
+	}
+	g.Build()
+
+	for domain, match := range map[string]bool{
+		"example.com":     true,
+		"www.example.com": true,
+		"wwwexample.com":  false,
+		"google.com":      true,
+		"www.google.com":  false,
+		"a.b.com":         true,
+		"c.a.b.com":       true,
+		"b.com":           false,
+		"x.y.com":         true,
+		"z.x.y.com":       true,
+		"y.com":           false,
+		"myads.net":       true,
+		"123.test":        true,
+		"a123.test":       false,
+		"com":             false,
+		"":                false,
+	} {
+		if actual := len(g.Match(domain)) > 0; actual != match {
+			t.Error("domain ", domain, ": ", actual, ", want ", match)
+		}
+	}
+}
+
+func TestEmptyHybridMatcherGroup(t *testing.T) {
+	g := NewHybridMatcherGroup()
+	g.Build()
+	if r := g.Match("example.com"); len(r) != 0 {
+		t.Error("Expect [], but ", r)
+	}
+}
+
+// TestHybridMatcherGroupRandom checks HybridMatcherGroup against
+// MphMatcherGroup on random domains, across many blocks.
+func TestHybridMatcherGroupRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	randomDomain := func() string {
+		domain := []string{"com", "net", "org", "cn"}[r.Intn(4)]
+		for labels := r.Intn(3) + 1; labels > 0; labels-- {
+			domain = strconv.Itoa(r.Intn(300)) + "." + domain
+		}
+		return domain
+	}
+
+	hybrid := NewHybridMatcherGroup()
+	mph := NewMphMatcherGroup()
+	for i := 0; i < 3000; i++ {
+		domain := randomDomain()
+		t := []Type{Full, Domain}[r.Intn(2)]
+		_, err := hybrid.AddPattern(domain, t)
+		common.Must(err)
+		_, err = mph.AddPattern(domain, t)
+		common.Must(err)
+	}
+	hybrid.Build()
+	mph.Build()
+
+	for i := 0; i < 20000; i++ {
+		domain := randomDomain()
+		if len(hybrid.Match(domain)) != len(mph.Match(domain)) {
+			t.Fatal("unexpected match of ", domain)
+		}
+	}
+}
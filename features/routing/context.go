@@ -38,6 +38,12 @@ type Context interface {
 	// GetAttributes returns extra attributes from the conneciont content.
 	GetAttributes() map[string]string
 
+	// GetProcessName returns the name of the local process the connection is from, if found.
+	GetProcessName() string
+
+	// GetUID returns the user ID of the local process the connection is from, and whether it is found.
+	GetUID() (uint32, bool)
+
 	// GetSkipDNSResolve returns a flag switch for weather skip dns resolve during route pick.
 	GetSkipDNSResolve() bool
 }
@@ -1,7 +1,10 @@
 package routing
 
 import (
+	"time"
+
 	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/serial"
 	"github.com/xtls/xray-core/features"
 )
 
@@ -29,6 +32,60 @@ type Route interface {
 	GetOutboundTag() string
 }
 
+// RuleManager is implemented by routers whose rules can be changed while
+// running. Routes being picked during a change use either the old or the new
+// rules, never a mix of them. It is obtained from the Router feature with a
+// type assertion:
+//
+//	if manager, ok := router.(routing.RuleManager); ok {
+//		infos := manager.ListRules()
+//	}
+//
+// xray:api:beta
+type RuleManager interface {
+	// AddRule adds the rules and balancers of a router config, before the
+	// existing rules, or after them if shouldAppend is true.
+	AddRule(config *serial.TypedMessage, shouldAppend bool) error
+
+	// RemoveRule removes the rules with the given rule tag.
+	RemoveRule(ruleTag string) error
+
+	// ReplaceRules replaces all rules and balancers with those of a router config.
+	ReplaceRules(config *serial.TypedMessage) error
+
+	// ListRules returns the rules in the order they are applied, with the
+	// number of routes each rule picked.
+	ListRules() []*RuleInfo
+
+	// ListBalancers returns the balancers sorted by tag, with the number of
+	// times each outbound was picked.
+	ListBalancers() []*BalancerInfo
+}
+
+// RuleInfo describes a routing rule. Rules without a rule tag are identified
+// by their index.
+type RuleInfo struct {
+	Index       int
+	RuleTag     string
+	OutboundTag string
+	BalancerTag string
+
+	// Hits is the number of routes picked by the rule, and LastHit the time
+	// of the last one, or zero if the rule never matched.
+	Hits    uint64
+	LastHit time.Time
+}
+
+// BalancerInfo describes a routing balancer.
+type BalancerInfo struct {
+	Tag       string
+	Selectors []string
+	Strategy  string
+
+	// Picks is the number of times each outbound was picked, by outbound tag.
+	Picks map[string]uint64
+}
+
 // RouterType return the type of Router interface. Can be used to implement common.HasType.
 //
 // xray:api:stable
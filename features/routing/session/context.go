@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/process"
 	"github.com/xtls/xray-core/common/session"
 	"github.com/xtls/xray-core/features/routing"
 )
@@ -13,6 +14,9 @@ type Context struct {
 	Inbound  *session.Inbound
 	Outbound *session.Outbound
 	Content  *session.Content
+
+	process       *process.Info
+	processLoaded bool
 }
 
 // GetInboundTag implements routing.Context.
@@ -114,6 +118,41 @@ func (ctx *Context) GetAttributes() map[string]string {
 	return ctx.Content.Attributes
 }
 
+// getProcess finds the local process the connection is from, on first use.
+func (ctx *Context) getProcess() *process.Info {
+	if ctx.processLoaded {
+		return ctx.process
+	}
+	ctx.processLoaded = true
+	if ctx.Inbound == nil || !ctx.Inbound.Source.IsValid() {
+		return nil
+	}
+	source := ctx.Inbound.Source
+	network := source.Network
+	if network == net.Network_Unknown && ctx.Outbound != nil {
+		network = ctx.Outbound.Target.Network
+	}
+	// Connections from other hosts have no local process.
+	ctx.process, _ = process.FindProcess(network, source)
+	return ctx.process
+}
+
+// GetProcessName implements routing.Context.
+func (ctx *Context) GetProcessName() string {
+	if info := ctx.getProcess(); info != nil {
+		return info.Name
+	}
+	return ""
+}
+
+// GetUID implements routing.Context.
+func (ctx *Context) GetUID() (uint32, bool) {
+	if info := ctx.getProcess(); info != nil {
+		return info.UID, true
+	}
+	return 0, false
+}
+
 // GetSkipDNSResolve implements routing.Context.
 func (ctx *Context) GetSkipDNSResolve() bool {
 	if ctx.Content == nil {
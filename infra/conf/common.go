@@ -45,14 +45,20 @@ func (v *Address) UnmarshalJSON(data []byte) error {
 	if err := json.Unmarshal(data, &rawStr); err != nil {
 		return newError("invalid address: ", string(data)).Base(err)
 	}
-	if strings.HasPrefix(rawStr, "env:") {
-		rawStr = platform.NewEnvFlag(rawStr[4:]).GetValue(func() string { return "" })
-	}
-	v.Address = net.ParseAddress(rawStr)
+	v.Address = net.ParseAddress(expandEnv(rawStr))
 
 	return nil
 }
 
+// expandEnv returns the value of the environment variable NAME for "env:NAME",
+// and s itself otherwise.
+func expandEnv(s string) string {
+	if strings.HasPrefix(s, "env:") {
+		return platform.NewEnvFlag(s[4:]).GetValue(func() string { return "" })
+	}
+	return s
+}
+
 func (v *Address) Build() *net.IPOrDomain {
 	return net.NewIPOrDomain(v.Address)
 }
@@ -0,0 +1,74 @@
+package conf
+
+import (
+	"encoding/json"
+	"runtime"
+	"strconv"
+	"syscall"
+
+	"github.com/xtls/xray-core/common/net"
+)
+
+// InboundFallback is a fallback of an inbound that hands unrecognized connections
+// over to another server, e.g. VLESS and Trojan.
+type InboundFallback struct {
+	Name string          `json:"name"`
+	Alpn string          `json:"alpn"`
+	Path string          `json:"path"`
+	Type string          `json:"type"`
+	Dest json.RawMessage `json:"dest"`
+	Xver uint64          `json:"xver"`
+}
+
+// VLessInboundFallback is an alias of InboundFallback, kept for compatibility.
+type VLessInboundFallback = InboundFallback
+
+// parse validates the fallback and returns its network type and destination.
+// A port-only "dest" is expanded to 127.0.0.1, and an omitted "type" is derived from "dest".
+func (fb *InboundFallback) parse(protocolName string) (string, string, error) {
+	var i uint16
+	var dest string
+	if err := json.Unmarshal(fb.Dest, &i); err == nil {
+		dest = strconv.Itoa(int(i))
+	} else {
+		_ = json.Unmarshal(fb.Dest, &dest)
+	}
+	/*
+		if fb.Alpn == "h2" && fb.Path != "" {
+			return "", "", newError(protocolName, ` fallbacks: "alpn":"h2" doesn't support "path"`)
+		}
+	*/
+	if fb.Path != "" && fb.Path[0] != '/' {
+		return "", "", newError(protocolName, ` fallbacks: "path" must be empty or start with "/"`)
+	}
+	typ := fb.Type
+	if typ == "" && dest != "" {
+		if dest == "serve-ws-none" {
+			typ = "serve"
+		} else {
+			switch dest[0] {
+			case '@', '/':
+				typ = "unix"
+				if dest[0] == '@' && len(dest) > 1 && dest[1] == '@' && (runtime.GOOS == "linux" || runtime.GOOS == "android") {
+					fullAddr := make([]byte, len(syscall.RawSockaddrUnix{}.Path)) // may need padding to work with haproxy
+					copy(fullAddr, dest[1:])
+					dest = string(fullAddr)
+				}
+			default:
+				if _, err := strconv.Atoi(dest); err == nil {
+					dest = "127.0.0.1:" + dest
+				}
+				if _, _, err := net.SplitHostPort(dest); err == nil {
+					typ = "tcp"
+				}
+			}
+		}
+	}
+	if typ == "" {
+		return "", "", newError(protocolName, ` fallbacks: please fill in a valid value for every "dest"`)
+	}
+	if fb.Xver > 2 {
+		return "", "", newError(protocolName, ` fallbacks: invalid PROXY protocol version, "xver" only accepts 0, 1, 2`)
+	}
+	return typ, dest, nil
+}
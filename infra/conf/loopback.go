@@ -0,0 +1,14 @@
+package conf
+
+import (
+	"github.com/xtls/xray-core/proxy/loopback"
+	"google.golang.org/protobuf/proto"
+)
+
+type LoopbackConfig struct {
+	InboundTag string `json:"inboundTag"`
+}
+
+func (l LoopbackConfig) Build() (proto.Message, error) {
+	return &loopback.Config{InboundTag: l.InboundTag}, nil
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/xtls/xray-core/app/router"
+	"github.com/xtls/xray-core/common/mmdb"
 	"github.com/xtls/xray-core/common/net"
 	"github.com/xtls/xray-core/common/platform/filesystem"
 	"google.golang.org/protobuf/proto"
@@ -84,6 +85,9 @@ func (c *RouterConfig) getDomainStrategy() router.Config_DomainStrategy {
 }
 
 func (c *RouterConfig) Build() (*router.Config, error) {
+	release := cacheMMDB()
+	defer release()
+
 	config := new(router.Config)
 	config.DomainStrategy = c.getDomainStrategy()
 
@@ -118,10 +122,96 @@ func (c *RouterConfig) Build() (*router.Config, error) {
 	return config, nil
 }
 
+// TimeConfig is the time windows of a routing rule, such as
+// {"ranges": ["09:00-18:00"], "weekdays": ["mon-fri"], "timezone": "Europe/Berlin"}.
+type TimeConfig struct {
+	Ranges   StringList `json:"ranges"`
+	Weekdays StringList `json:"weekdays"`
+	Timezone string     `json:"timezone"`
+}
+
+var weekdayNames = []string{"sunday", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday"}
+
+// parseWeekday parses a weekday by its name, the first three letters of its
+// name, or its number where 0 is Sunday.
+func parseWeekday(s string) (uint32, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for i, name := range weekdayNames {
+		if s == name || s == name[:3] {
+			return uint32(i), nil
+		}
+	}
+	if day, err := strconv.ParseUint(s, 10, 32); err == nil && day < 7 {
+		return uint32(day), nil
+	}
+	return 0, newError("invalid weekday: ", s)
+}
+
+// parseTimeOfDay parses "hh:mm" into minutes since midnight. "24:00" is the
+// end of the day.
+func parseTimeOfDay(s string) (uint32, error) {
+	hour, minute, found := strings.Cut(strings.TrimSpace(s), ":")
+	if !found {
+		return 0, newError("invalid time: ", s)
+	}
+	h, err := strconv.ParseUint(hour, 10, 32)
+	if err != nil {
+		return 0, newError("invalid time: ", s)
+	}
+	m, err := strconv.ParseUint(minute, 10, 32)
+	if err != nil || m > 59 || h > 24 || (h == 24 && m != 0) {
+		return 0, newError("invalid time: ", s)
+	}
+	return uint32(h*60 + m), nil
+}
+
+func (c *TimeConfig) Build() (*router.TimeCondition, error) {
+	condition := &router.TimeCondition{
+		Timezone: c.Timezone,
+	}
+	for _, r := range c.Ranges {
+		start, end, found := strings.Cut(r, "-")
+		if !found {
+			return nil, newError("invalid time range: ", r)
+		}
+		startMinute, err := parseTimeOfDay(start)
+		if err != nil {
+			return nil, err
+		}
+		endMinute, err := parseTimeOfDay(end)
+		if err != nil {
+			return nil, err
+		}
+		condition.Range = append(condition.Range, &router.TimeRange{Start: startMinute, End: endMinute})
+	}
+	for _, w := range c.Weekdays {
+		first, last, isRange := strings.Cut(w, "-")
+		from, err := parseWeekday(first)
+		if err != nil {
+			return nil, err
+		}
+		to := from
+		if isRange {
+			if to, err = parseWeekday(last); err != nil {
+				return nil, err
+			}
+		}
+		// Ranges such as "sat-sun" wrap around the week.
+		for day := from; ; day = (day + 1) % 7 {
+			condition.Weekday = append(condition.Weekday, day)
+			if day == to {
+				break
+			}
+		}
+	}
+	return condition, nil
+}
+
 type RouterRule struct {
 	Type        string `json:"type"`
 	OutboundTag string `json:"outboundTag"`
 	BalancerTag string `json:"balancerTag"`
+	RuleTag     string `json:"ruleTag"`
 
 	DomainMatcher string `json:"domainMatcher"`
 }
@@ -184,6 +274,22 @@ var (
 	SiteCache = make(map[string]*router.GeoSite)
 )
 
+// mmdbCache holds the readers of the MaxMind DBs of the config being built,
+// as many rules look up the same DB. DBs are not cached outside of a build.
+var mmdbCache map[string]*mmdb.Reader
+
+// cacheMMDB caches MaxMind DBs until the returned function is called, unless
+// they are already cached for an enclosing build.
+func cacheMMDB() (release func()) {
+	if mmdbCache != nil {
+		return func() {}
+	}
+	mmdbCache = make(map[string]*mmdb.Reader)
+	return func() {
+		mmdbCache = nil
+	}
+}
+
 func loadFile(file string) ([]byte, error) {
 	if FileCache[file] == nil {
 		bs, err := filesystem.ReadAsset(file)
@@ -223,6 +329,56 @@ func loadIP(file, code string) ([]*router.CIDR, error) {
 	return IPCache[index].Cidr, nil
 }
 
+// loadMMDB loads the networks of a country, or of an autonomous system if
+// asn is not zero, from a MaxMind DB file.
+func loadMMDB(file, country string, asn uint) ([]*router.CIDR, error) {
+	reader, err := loadMMDBReader(file)
+	if err != nil {
+		return nil, err
+	}
+	prefixes, err := reader.Select(func(data interface{}) bool {
+		if asn != 0 {
+			return mmdb.ASN(data) == asn
+		}
+		return strings.EqualFold(mmdb.CountryCode(data), country)
+	})
+	if err != nil {
+		return nil, newError("failed to read MaxMind DB: ", file).Base(err)
+	}
+	if len(prefixes) == 0 {
+		if asn != 0 {
+			return nil, newError("AS", asn, " not found in ", file)
+		}
+		return nil, newError("code not found in ", file, ": ", country)
+	}
+	cidrs := make([]*router.CIDR, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		cidrs = append(cidrs, &router.CIDR{
+			Ip:     prefix.Addr().AsSlice(),
+			Prefix: uint32(prefix.Bits()),
+		})
+	}
+	return cidrs, nil
+}
+
+func loadMMDBReader(file string) (*mmdb.Reader, error) {
+	if reader := mmdbCache[file]; reader != nil {
+		return reader, nil
+	}
+	bs, err := loadFile(file)
+	if err != nil {
+		return nil, newError("failed to load file: ", file).Base(err)
+	}
+	reader, err := mmdb.New(bs)
+	if err != nil {
+		return nil, newError("failed to read MaxMind DB: ", file).Base(err)
+	}
+	if mmdbCache != nil {
+		mmdbCache[file] = reader
+	}
+	return reader, nil
+}
+
 func loadSite(file, code string) ([]*router.Domain, error) {
 	index := file + ":" + code
 	if SiteCache[index] == nil {
@@ -363,14 +519,31 @@ func loadGeositeWithAttr(file string, siteWithAttr string) ([]*router.Domain, er
 	return filteredDomains, nil
 }
 
-func parseDomainRule(domain string) ([]*router.Domain, error) {
+// parseDomainRule parses a domain rule into the domains it matches, or into
+// a domain list for block lists with exception rules.
+func parseDomainRule(domain string) ([]*router.Domain, *router.DomainList, error) {
+	for prefix, parse := range map[string]func([]byte) *blockList{
+		"hosts:":   parseHostsFile,
+		"adblock:": parseAdblockFilters,
+	} {
+		if file, ok := strings.CutPrefix(domain, prefix); ok {
+			list, err := loadBlockList(file, parse)
+			if err != nil {
+				return nil, nil, err
+			}
+			if len(list.exceptions) == 0 {
+				return list.domains, nil, nil
+			}
+			return nil, &router.DomainList{Domain: list.domains, Exception: list.exceptions}, nil
+		}
+	}
 	if strings.HasPrefix(domain, "geosite:") {
 		country := strings.ToUpper(domain[8:])
 		domains, err := loadGeositeWithAttr("geosite.dat", country)
 		if err != nil {
-			return nil, newError("failed to load geosite: ", country).Base(err)
+			return nil, nil, newError("failed to load geosite: ", country).Base(err)
 		}
-		return domains, nil
+		return domains, nil, nil
 	}
 	isExtDatFile := 0
 	{
@@ -386,15 +559,15 @@ func parseDomainRule(domain string) ([]*router.Domain, error) {
 	if isExtDatFile != 0 {
 		kv := strings.Split(domain[isExtDatFile:], ":")
 		if len(kv) != 2 {
-			return nil, newError("invalid external resource: ", domain)
+			return nil, nil, newError("invalid external resource: ", domain)
 		}
 		filename := kv[0]
 		country := kv[1]
 		domains, err := loadGeositeWithAttr(filename, country)
 		if err != nil {
-			return nil, newError("failed to load external sites: ", country, " from ", filename).Base(err)
+			return nil, nil, newError("failed to load external sites: ", country, " from ", filename).Base(err)
 		}
-		return domains, nil
+		return domains, nil, nil
 	}
 
 	domainRule := new(router.Domain)
@@ -423,14 +596,29 @@ func parseDomainRule(domain string) ([]*router.Domain, error) {
 		case !strings.Contains(substr, "."):
 			domainRule.Value = "^[^.]*" + substr + "[^.]*$"
 		default:
-			return nil, newError("substr in dotless rule should not contain a dot: ", substr)
+			return nil, nil, newError("substr in dotless rule should not contain a dot: ", substr)
 		}
 
 	default:
 		domainRule.Type = router.Domain_Plain
 		domainRule.Value = domain
 	}
-	return []*router.Domain{domainRule}, nil
+	return []*router.Domain{domainRule}, nil, nil
+}
+
+// splitRuleSets separates the "ruleset:" files of a rule field from its
+// other entries.
+func splitRuleSets(list StringList) (StringList, []string) {
+	var entries StringList
+	var ruleSets []string
+	for _, entry := range list {
+		if file, ok := strings.CutPrefix(entry, "ruleset:"); ok {
+			ruleSets = append(ruleSets, file)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, ruleSets
 }
 
 func ToCidrList(ips StringList) ([]*router.GeoIP, error) {
@@ -460,6 +648,14 @@ func ToCidrList(ips StringList) ([]*router.GeoIP, error) {
 			})
 			continue
 		}
+		if strings.HasPrefix(ip, "mmdb:") || strings.HasPrefix(ip, "asn:") {
+			geoip, err := parseMMDBRule(ip)
+			if err != nil {
+				return nil, err
+			}
+			geoipList = append(geoipList, geoip)
+			continue
+		}
 		isExtDatFile := 0
 		{
 			const prefix = "ext:"
@@ -518,67 +714,182 @@ func ToCidrList(ips StringList) ([]*router.GeoIP, error) {
 	return geoipList, nil
 }
 
+// parseMMDBRule parses "mmdb:file:country" and "asn:[file:]number". The
+// default file of ASNs is asn.mmdb. Prefix the country or number with "!"
+// to match the other IPs.
+func parseMMDBRule(ip string) (*router.GeoIP, error) {
+	var kv []string
+	isASN := strings.HasPrefix(ip, "asn:")
+	if isASN {
+		kv = strings.Split(ip[len("asn:"):], ":")
+		if len(kv) == 1 {
+			kv = []string{"asn.mmdb", kv[0]}
+		}
+	} else {
+		kv = strings.Split(ip[len("mmdb:"):], ":")
+	}
+	if len(kv) != 2 {
+		return nil, newError("invalid MaxMind DB rule: ", ip)
+	}
+
+	filename, code := kv[0], kv[1]
+	isReverseMatch := false
+	if strings.HasPrefix(code, "!") {
+		code = code[1:]
+		isReverseMatch = true
+	}
+	if len(filename) == 0 || len(code) == 0 {
+		return nil, newError("empty filename or empty code in rule")
+	}
+
+	var asn uint64
+	if isASN {
+		var err error
+		if asn, err = strconv.ParseUint(strings.TrimPrefix(strings.ToUpper(code), "AS"), 10, 32); err != nil || asn == 0 {
+			return nil, newError("invalid ASN: ", code)
+		}
+		code = "AS" + strconv.FormatUint(asn, 10)
+	}
+	cidrs, err := loadMMDB(filename, code, uint(asn))
+	if err != nil {
+		return nil, newError("failed to load IPs: ", code, " from ", filename).Base(err)
+	}
+	return &router.GeoIP{
+		CountryCode:  strings.ToUpper("mmdb_" + filename + "_" + code),
+		Cidr:         cidrs,
+		ReverseMatch: isReverseMatch,
+	}, nil
+}
+
 func parseFieldRule(msg json.RawMessage) (*router.RoutingRule, error) {
-	type RawFieldRule struct {
-		RouterRule
-		Domain     *StringList       `json:"domain"`
-		Domains    *StringList       `json:"domains"`
-		IP         *StringList       `json:"ip"`
-		Port       *PortList         `json:"port"`
-		Network    *NetworkList      `json:"network"`
-		SourceIP   *StringList       `json:"source"`
-		SourcePort *PortList         `json:"sourcePort"`
-		User       *StringList       `json:"user"`
-		InboundTag *StringList       `json:"inboundTag"`
-		Protocols  *StringList       `json:"protocol"`
-		Attributes map[string]string `json:"attrs"`
+	rawRule := new(RouterRule)
+	if err := json.Unmarshal(msg, rawRule); err != nil {
+		return nil, err
 	}
-	rawFieldRule := new(RawFieldRule)
-	err := json.Unmarshal(msg, rawFieldRule)
+	rule, err := parseRuleConditions(msg)
 	if err != nil {
 		return nil, err
 	}
 
-	rule := new(router.RoutingRule)
 	switch {
-	case len(rawFieldRule.OutboundTag) > 0:
+	case len(rawRule.OutboundTag) > 0:
 		rule.TargetTag = &router.RoutingRule_Tag{
-			Tag: rawFieldRule.OutboundTag,
+			Tag: rawRule.OutboundTag,
 		}
-	case len(rawFieldRule.BalancerTag) > 0:
+	case len(rawRule.BalancerTag) > 0:
 		rule.TargetTag = &router.RoutingRule_BalancingTag{
-			BalancingTag: rawFieldRule.BalancerTag,
+			BalancingTag: rawRule.BalancerTag,
 		}
 	default:
 		return nil, newError("neither outboundTag nor balancerTag is specified in routing rule")
 	}
 
+	rule.RuleTag = rawRule.RuleTag
+	return rule, nil
+}
+
+// LogicalRuleConfig is a boolean expression over sub-rules, such as
+// {"operator": "not", "rules": [{"port": 22}]}. Sub-rules have the fields of
+// field rules, without targets.
+type LogicalRuleConfig struct {
+	Operator string            `json:"operator"`
+	Rules    []json.RawMessage `json:"rules"`
+}
+
+func (c *LogicalRuleConfig) Build() (*router.LogicalRule, error) {
+	rule := new(router.LogicalRule)
+	switch strings.ToLower(c.Operator) {
+	case "and":
+		rule.Operator = router.LogicalRule_And
+	case "or":
+		rule.Operator = router.LogicalRule_Or
+	case "not":
+		rule.Operator = router.LogicalRule_Not
+		if len(c.Rules) != 1 {
+			return nil, newError("not operator takes exactly one rule")
+		}
+	default:
+		return nil, newError("unknown operator: ", c.Operator)
+	}
+	if len(c.Rules) == 0 {
+		return nil, newError("no rules in logical rule")
+	}
+	for _, msg := range c.Rules {
+		subRule, err := parseRuleConditions(msg)
+		if err != nil {
+			return nil, newError("invalid sub-rule").Base(err)
+		}
+		rule.Rule = append(rule.Rule, subRule)
+	}
+	return rule, nil
+}
+
+// parseRuleConditions parses the conditions of a field rule.
+func parseRuleConditions(msg json.RawMessage) (*router.RoutingRule, error) {
+	type RawFieldRule struct {
+		RouterRule
+		Domain     *StringList        `json:"domain"`
+		Domains    *StringList        `json:"domains"`
+		IP         *StringList        `json:"ip"`
+		Port       *PortList          `json:"port"`
+		Network    *NetworkList       `json:"network"`
+		SourceIP   *StringList        `json:"source"`
+		SourcePort *PortList          `json:"sourcePort"`
+		User       *StringList        `json:"user"`
+		InboundTag *StringList        `json:"inboundTag"`
+		Protocols  *StringList        `json:"protocol"`
+		Attributes map[string]string  `json:"attrs"`
+		Time       *TimeConfig        `json:"time"`
+		Process    *StringList        `json:"process"`
+		UID        []uint32           `json:"uid"`
+		Logical    *LogicalRuleConfig `json:"logical"`
+	}
+	rawFieldRule := new(RawFieldRule)
+	err := json.Unmarshal(msg, rawFieldRule)
+	if err != nil {
+		return nil, err
+	}
+
+	rule := new(router.RoutingRule)
+
 	if rawFieldRule.DomainMatcher != "" {
 		rule.DomainMatcher = rawFieldRule.DomainMatcher
 	}
 
 	if rawFieldRule.Domain != nil {
-		for _, domain := range *rawFieldRule.Domain {
-			rules, err := parseDomainRule(domain)
+		var domains StringList
+		domains, rule.DomainRuleset = splitRuleSets(*rawFieldRule.Domain)
+		for _, domain := range domains {
+			rules, list, err := parseDomainRule(domain)
 			if err != nil {
 				return nil, newError("failed to parse domain rule: ", domain).Base(err)
 			}
 			rule.Domain = append(rule.Domain, rules...)
+			if list != nil {
+				rule.DomainList = append(rule.DomainList, list)
+			}
 		}
 	}
 
 	if rawFieldRule.Domains != nil {
-		for _, domain := range *rawFieldRule.Domains {
-			rules, err := parseDomainRule(domain)
+		domains, ruleSets := splitRuleSets(*rawFieldRule.Domains)
+		rule.DomainRuleset = append(rule.DomainRuleset, ruleSets...)
+		for _, domain := range domains {
+			rules, list, err := parseDomainRule(domain)
 			if err != nil {
 				return nil, newError("failed to parse domain rule: ", domain).Base(err)
 			}
 			rule.Domain = append(rule.Domain, rules...)
+			if list != nil {
+				rule.DomainList = append(rule.DomainList, list)
+			}
 		}
 	}
 
 	if rawFieldRule.IP != nil {
-		geoipList, err := ToCidrList(*rawFieldRule.IP)
+		var ips StringList
+		ips, rule.IpRuleset = splitRuleSets(*rawFieldRule.IP)
+		geoipList, err := ToCidrList(ips)
 		if err != nil {
 			return nil, err
 		}
@@ -594,7 +905,9 @@ func parseFieldRule(msg json.RawMessage) (*router.RoutingRule, error) {
 	}
 
 	if rawFieldRule.SourceIP != nil {
-		geoipList, err := ToCidrList(*rawFieldRule.SourceIP)
+		var ips StringList
+		ips, rule.SourceRuleset = splitRuleSets(*rawFieldRule.SourceIP)
+		geoipList, err := ToCidrList(ips)
 		if err != nil {
 			return nil, err
 		}
@@ -627,6 +940,30 @@ func parseFieldRule(msg json.RawMessage) (*router.RoutingRule, error) {
 		rule.Attributes = rawFieldRule.Attributes
 	}
 
+	if rawFieldRule.Process != nil {
+		rule.ProcessName = *rawFieldRule.Process
+	}
+
+	if len(rawFieldRule.UID) > 0 {
+		rule.Uid = rawFieldRule.UID
+	}
+
+	if rawFieldRule.Logical != nil {
+		logical, err := rawFieldRule.Logical.Build()
+		if err != nil {
+			return nil, newError("invalid logical rule").Base(err)
+		}
+		rule.Logical = logical
+	}
+
+	if rawFieldRule.Time != nil {
+		timeCondition, err := rawFieldRule.Time.Build()
+		if err != nil {
+			return nil, newError("invalid time condition").Base(err)
+		}
+		rule.Time = timeCondition
+	}
+
 	return rule, nil
 }
 
@@ -674,7 +1011,8 @@ func parseChinaIPRule(data []byte) (*router.RoutingRule, error) {
 		TargetTag: &router.RoutingRule_Tag{
 			Tag: rawRule.OutboundTag,
 		},
-		Cidr: chinaIPs,
+		Cidr:    chinaIPs,
+		RuleTag: rawRule.RuleTag,
 	}, nil
 }
 
@@ -692,6 +1030,7 @@ func parseChinaSitesRule(data []byte) (*router.RoutingRule, error) {
 		TargetTag: &router.RoutingRule_Tag{
 			Tag: rawRule.OutboundTag,
 		},
-		Domain: domains,
+		Domain:  domains,
+		RuleTag: rawRule.RuleTag,
 	}, nil
 }
@@ -0,0 +1,141 @@
+package conf
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+
+	"github.com/xtls/xray-core/app/router"
+	"github.com/xtls/xray-core/common/net"
+)
+
+// blockList is the domains of a hosts file or Adblock Plus filter list.
+type blockList struct {
+	domains    []*router.Domain
+	exceptions []*router.Domain
+	// skipped is the number of unsupported rules.
+	skipped int
+}
+
+// isHostname reports whether s is a hostname that can be matched by domain
+// rules.
+func isHostname(s string) bool {
+	if s == "" || len(s) > 253 || strings.HasPrefix(s, ".") || strings.HasSuffix(s, ".") {
+		return false
+	}
+	for _, c := range s {
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '-', c == '.', c == '_':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// parseHostsFile parses a hosts file, such as "0.0.0.0 ads.example.com".
+// Each hostname is matched in full. Lines with a single hostname and no
+// address are also accepted.
+func parseHostsFile(content []byte) *blockList {
+	list := new(blockList)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(strings.ToLower(line))
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) > 1 {
+			if net.ParseIP(fields[0]) == nil {
+				list.skipped++
+				continue
+			}
+			fields = fields[1:]
+		}
+		for _, host := range fields {
+			switch host {
+			case "localhost", "localhost.localdomain", "local", "broadcasthost", "ip6-localhost", "ip6-loopback":
+				continue
+			}
+			if !isHostname(host) {
+				list.skipped++
+				continue
+			}
+			list.domains = append(list.domains, &router.Domain{Type: router.Domain_Full, Value: host})
+		}
+	}
+	return list
+}
+
+// parseAdblockFilters parses an Adblock Plus filter list. Only rules
+// blocking whole domains are supported: "||example.com^" matches the domain
+// and its subdomains, a bare "example.com" matches the domain only, and
+// "@@" marks exception rules. Cosmetic filters, URL patterns and rules with
+// type options are skipped.
+func parseAdblockFilters(content []byte) *blockList {
+	list := new(blockList)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "!") || strings.HasPrefix(line, "[") {
+			continue
+		}
+		if domain := parseAdblockFilter(strings.TrimPrefix(line, "@@")); domain == nil {
+			list.skipped++
+		} else if strings.HasPrefix(line, "@@") {
+			list.exceptions = append(list.exceptions, domain)
+		} else {
+			list.domains = append(list.domains, domain)
+		}
+	}
+	return list
+}
+
+func parseAdblockFilter(filter string) *router.Domain {
+	if strings.Contains(filter, "#") {
+		// Cosmetic filters, such as "example.com##.ad".
+		return nil
+	}
+	if pattern, options, found := strings.Cut(filter, "$"); found {
+		for _, option := range strings.Split(options, ",") {
+			switch option {
+			case "important", "all", "document", "doc":
+			default:
+				return nil
+			}
+		}
+		filter = pattern
+	}
+	domainType := router.Domain_Full
+	if strings.HasPrefix(filter, "||") {
+		domainType = router.Domain_Domain
+		filter = filter[2:]
+	}
+	filter = strings.TrimSuffix(strings.TrimSuffix(filter, "|"), "^")
+	filter = strings.ToLower(filter)
+	if !isHostname(filter) {
+		return nil
+	}
+	return &router.Domain{Type: domainType, Value: filter}
+}
+
+// loadBlockList loads the domains of a hosts file or Adblock Plus filter
+// list.
+func loadBlockList(file string, parse func([]byte) *blockList) (*blockList, error) {
+	bs, err := loadFile(file)
+	if err != nil {
+		return nil, newError("failed to load file: ", file).Base(err)
+	}
+	list := parse(bs)
+	if list.skipped > 0 {
+		newError("skipped ", list.skipped, " unsupported rules in ", file).AtInfo().WriteToLog()
+	}
+	if len(list.domains) == 0 {
+		// Exception rules alone would match almost all domains.
+		return nil, newError("no blocking rules in ", file)
+	}
+	return list, nil
+}
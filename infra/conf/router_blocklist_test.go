@@ -0,0 +1,86 @@
+package conf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/xtls/xray-core/app/router"
+	"github.com/xtls/xray-core/common"
+)
+
+func TestParseAdblockFilters(t *testing.T) {
+	list := parseAdblockFilters([]byte(`[Adblock Plus 2.0]
+! comment
+||ads.example.com^
+tracker.example.com
+@@||ok.ads.example.com^
+||video.example.com^$important
+example.com##.banner
+||example.com/ads/*
+||script.example.com^$script
+`))
+	domains := []*router.Domain{
+		{Type: router.Domain_Domain, Value: "ads.example.com"},
+		{Type: router.Domain_Full, Value: "tracker.example.com"},
+		{Type: router.Domain_Domain, Value: "video.example.com"},
+	}
+	if len(list.domains) != len(domains) {
+		t.Fatal("unexpected domains: ", list.domains)
+	}
+	for i, d := range domains {
+		if list.domains[i].Type != d.Type || list.domains[i].Value != d.Value {
+			t.Error("domain ", i, ": ", list.domains[i], ", want ", d)
+		}
+	}
+	if len(list.exceptions) != 1 || list.exceptions[0].Type != router.Domain_Domain || list.exceptions[0].Value != "ok.ads.example.com" {
+		t.Error("unexpected exceptions: ", list.exceptions)
+	}
+	if list.skipped != 3 {
+		t.Error("skipped: ", list.skipped)
+	}
+}
+
+func TestParseHostsFile(t *testing.T) {
+	list := parseHostsFile([]byte(`# comment
+127.0.0.1 localhost
+0.0.0.0 ads.example.com tracker.example.com # trailing comment
+::1 ip6-localhost
+tracker.example.org
+`))
+	if len(list.domains) != 3 || list.domains[0].Value != "ads.example.com" || list.domains[2].Value != "tracker.example.org" {
+		t.Error("unexpected domains: ", list.domains)
+	}
+	for _, d := range list.domains {
+		if d.Type != router.Domain_Full {
+			t.Error("unexpected type: ", d)
+		}
+	}
+}
+
+func TestBlockListRule(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XRAY_LOCATION_ASSET", dir)
+	common.Must(os.WriteFile(filepath.Join(dir, "hosts.txt"), []byte("0.0.0.0 ads.example.org\n"), 0o644))
+	common.Must(os.WriteFile(filepath.Join(dir, "filters.txt"), []byte("||example.com^\n@@||ok.example.com^\n"), 0o644))
+	common.Must(os.WriteFile(filepath.Join(dir, "exceptions.txt"), []byte("@@||ok.example.com^\n"), 0o644))
+
+	rule, err := parseFieldRule([]byte(`{
+		"domain": ["hosts:hosts.txt", "adblock:filters.txt", "domain:example.net"],
+		"outboundTag": "block"
+	}`))
+	common.Must(err)
+	if len(rule.Domain) != 2 || rule.Domain[0].Value != "ads.example.org" || rule.Domain[1].Value != "example.net" {
+		t.Error("unexpected domains: ", rule.Domain)
+	}
+	if len(rule.DomainList) != 1 || len(rule.DomainList[0].Domain) != 1 || len(rule.DomainList[0].Exception) != 1 {
+		t.Fatal("unexpected domain lists: ", rule.DomainList)
+	}
+	if rule.DomainList[0].Exception[0].Value != "ok.example.com" {
+		t.Error("unexpected exceptions: ", rule.DomainList[0].Exception)
+	}
+
+	if _, err := parseFieldRule([]byte(`{"domain": ["adblock:exceptions.txt"], "outboundTag": "block"}`)); err == nil {
+		t.Error("expected an error for a list of exception rules only")
+	}
+}
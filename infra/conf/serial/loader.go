@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"io"
 
+	"github.com/xtls/xray-core/app/router"
 	"github.com/xtls/xray-core/common/errors"
 	"github.com/xtls/xray-core/core"
 	"github.com/xtls/xray-core/infra/conf"
@@ -80,3 +81,21 @@ func LoadJSONConfig(reader io.Reader) (*core.Config, error) {
 
 	return pbConfig, nil
 }
+
+// LoadJSONRoutingConfig reads the JSON of a routing config, such as the
+// "routing" object of a config file, to change the rules of a running router.
+func LoadJSONRoutingConfig(reader io.Reader) (*router.Config, error) {
+	routingConfig := &conf.RouterConfig{}
+	decoder := json.NewDecoder(&json_reader.Reader{
+		Reader: reader,
+	})
+	if err := decoder.Decode(routingConfig); err != nil {
+		return nil, newError("failed to read routing config").Base(err)
+	}
+
+	config, err := routingConfig.Build()
+	if err != nil {
+		return nil, newError("failed to parse routing config").Base(err)
+	}
+	return config, nil
+}
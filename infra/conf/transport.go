@@ -7,7 +7,13 @@ import (
 )
 
 type TransportConfig struct {
-	TCPConfig *TCPConfig `json:"tcpSettings"`
+	TCPConfig  *TCPConfig       `json:"tcpSettings"`
+	KCPConfig  *KCPConfig       `json:"kcpSettings"`
+	WSConfig   *WebSocketConfig `json:"wsSettings"`
+	HTTPConfig *HTTPConfig      `json:"httpSettings"`
+	QUICConfig *QUICConfig      `json:"quicSettings"`
+	GRPCConfig *GRPCConfig      `json:"grpcSettings"`
+	GUNConfig  *GRPCConfig      `json:"gunSettings"`
 }
 
 // Build implements Buildable.
@@ -25,5 +31,63 @@ func (c *TransportConfig) Build() (*global.Config, error) {
 		})
 	}
 
+	if c.KCPConfig != nil {
+		ts, err := c.KCPConfig.Build()
+		if err != nil {
+			return nil, newError("failed to build mKCP config").Base(err).AtError()
+		}
+		config.TransportSettings = append(config.TransportSettings, &internet.TransportConfig{
+			ProtocolName: "mkcp",
+			Settings:     serial.ToTypedMessage(ts),
+		})
+	}
+
+	if c.WSConfig != nil {
+		ts, err := c.WSConfig.Build()
+		if err != nil {
+			return nil, newError("failed to build WebSocket config").Base(err)
+		}
+		config.TransportSettings = append(config.TransportSettings, &internet.TransportConfig{
+			ProtocolName: "websocket",
+			Settings:     serial.ToTypedMessage(ts),
+		})
+	}
+
+	if c.HTTPConfig != nil {
+		ts, err := c.HTTPConfig.Build()
+		if err != nil {
+			return nil, newError("failed to build HTTP config").Base(err)
+		}
+		config.TransportSettings = append(config.TransportSettings, &internet.TransportConfig{
+			ProtocolName: "http",
+			Settings:     serial.ToTypedMessage(ts),
+		})
+	}
+
+	if c.QUICConfig != nil {
+		qs, err := c.QUICConfig.Build()
+		if err != nil {
+			return nil, newError("failed to build QUIC config").Base(err)
+		}
+		config.TransportSettings = append(config.TransportSettings, &internet.TransportConfig{
+			ProtocolName: "quic",
+			Settings:     serial.ToTypedMessage(qs),
+		})
+	}
+
+	if c.GRPCConfig == nil {
+		c.GRPCConfig = c.GUNConfig
+	}
+	if c.GRPCConfig != nil {
+		gs, err := c.GRPCConfig.Build()
+		if err != nil {
+			return nil, newError("failed to build gRPC config").Base(err)
+		}
+		config.TransportSettings = append(config.TransportSettings, &internet.TransportConfig{
+			ProtocolName: "grpc",
+			Settings:     serial.ToTypedMessage(gs),
+		})
+	}
+
 	return config, nil
 }
@@ -1,16 +1,28 @@
 package conf
 
 import (
+	"bytes"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"math"
+	"net"
+	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/xtls/xray-core/common/platform/filesystem"
 	"github.com/xtls/xray-core/common/serial"
 	"github.com/xtls/xray-core/transport/internet"
+	"github.com/xtls/xray-core/transport/internet/grpc"
+	httpheader "github.com/xtls/xray-core/transport/internet/headers/http"
+	"github.com/xtls/xray-core/transport/internet/http"
+	"github.com/xtls/xray-core/transport/internet/kcp"
+	"github.com/xtls/xray-core/transport/internet/quic"
+	"github.com/xtls/xray-core/transport/internet/reality"
 	"github.com/xtls/xray-core/transport/internet/tcp"
 	"github.com/xtls/xray-core/transport/internet/tls"
+	"github.com/xtls/xray-core/transport/internet/websocket"
 	"google.golang.org/protobuf/proto"
 )
 
@@ -31,6 +43,84 @@ var (
 	}, "type", "")
 )
 
+type KCPConfig struct {
+	Mtu             *uint32         `json:"mtu"`
+	Tti             *uint32         `json:"tti"`
+	UpCap           *uint32         `json:"uplinkCapacity"`
+	DownCap         *uint32         `json:"downlinkCapacity"`
+	Congestion      *bool           `json:"congestion"`
+	ReadBufferSize  *uint32         `json:"readBufferSize"`
+	WriteBufferSize *uint32         `json:"writeBufferSize"`
+	HeaderConfig    json.RawMessage `json:"header"`
+	Seed            *string         `json:"seed"`
+}
+
+// Build implements Buildable.
+func (c *KCPConfig) Build() (proto.Message, error) {
+	config := new(kcp.Config)
+
+	if c.Mtu != nil {
+		mtu := *c.Mtu
+		if mtu < 576 || mtu > 1460 {
+			return nil, newError("invalid mKCP MTU size: ", mtu).AtError()
+		}
+		config.Mtu = &kcp.MTU{Value: mtu}
+	}
+	if c.Tti != nil {
+		tti := *c.Tti
+		if tti < 10 || tti > 100 {
+			return nil, newError("invalid mKCP TTI: ", tti).AtError()
+		}
+		config.Tti = &kcp.TTI{Value: tti}
+	}
+	if c.UpCap != nil {
+		config.UplinkCapacity = &kcp.UplinkCapacity{Value: *c.UpCap}
+	}
+	if c.DownCap != nil {
+		config.DownlinkCapacity = &kcp.DownlinkCapacity{Value: *c.DownCap}
+	}
+	if c.Congestion != nil {
+		config.Congestion = *c.Congestion
+	}
+	if c.ReadBufferSize != nil {
+		size := *c.ReadBufferSize
+		if size > 0 {
+			config.ReadBuffer = &kcp.ReadBuffer{Size: size * 1024 * 1024}
+		} else {
+			config.ReadBuffer = &kcp.ReadBuffer{Size: 512 * 1024}
+		}
+	}
+	if c.WriteBufferSize != nil {
+		size := *c.WriteBufferSize
+		if size > 0 {
+			config.WriteBuffer = &kcp.WriteBuffer{Size: size * 1024 * 1024}
+		} else {
+			config.WriteBuffer = &kcp.WriteBuffer{Size: 512 * 1024}
+		}
+	}
+	if len(c.HeaderConfig) > 0 {
+		headerConfig, _, err := kcpHeaderLoader.Load(c.HeaderConfig)
+		if err != nil {
+			return nil, newError("invalid mKCP header config.").Base(err).AtError()
+		}
+		builder, ok := headerConfig.(Buildable)
+		if !ok {
+			return nil, newError("unsupported mKCP header config.").AtError()
+		}
+		ts, err := builder.Build()
+		if err != nil {
+			return nil, newError("invalid mKCP header config").Base(err).AtError()
+		}
+		config.HeaderConfig = serial.ToTypedMessage(ts)
+	}
+
+	if c.Seed != nil {
+		config.Seed = &kcp.EncryptionSeed{Seed: *c.Seed}
+	}
+
+	return config, nil
+}
+
 type TCPConfig struct {
 	HeaderConfig        json.RawMessage `json:"header"`
 	AcceptProxyProtocol bool            `json:"acceptProxyProtocol"`
@@ -56,6 +146,161 @@ func (c *TCPConfig) Build() (proto.Message, error) {
 	return config, nil
 }
 
+type WebSocketConfig struct {
+	Host                string            `json:"host"`
+	Path                string            `json:"path"`
+	Headers             map[string]string `json:"headers"`
+	AcceptProxyProtocol bool              `json:"acceptProxyProtocol"`
+}
+
+// Build implements Buildable.
+func (c *WebSocketConfig) Build() (proto.Message, error) {
+	path := c.Path
+	header := make([]*websocket.Header, 0, 32)
+	for key, value := range c.Headers {
+		header = append(header, &websocket.Header{
+			Key:   key,
+			Value: value,
+		})
+	}
+	var ed uint32
+	if u, err := url.Parse(path); err == nil {
+		if q := u.Query(); q.Get("ed") != "" {
+			Ed, _ := strconv.Atoi(q.Get("ed"))
+			ed = uint32(Ed)
+			q.Del("ed")
+			u.RawQuery = q.Encode()
+			path = u.String()
+		}
+	}
+	// If the Host field is not set but a host header is, use the header, so that
+	// it is not overwritten by the destination address.
+	// Host priority: Host field > headers field > address.
+	host := c.Host
+	if host == "" {
+		for key, value := range c.Headers {
+			if strings.EqualFold(key, "host") {
+				host = value
+				break
+			}
+		}
+	}
+	config := &websocket.Config{
+		Host:   host,
+		Path:   path,
+		Header: header,
+		Ed:     ed,
+	}
+	if c.AcceptProxyProtocol {
+		config.AcceptProxyProtocol = c.AcceptProxyProtocol
+	}
+	return config, nil
+}
+
+type HTTPConfig struct {
+	Host               *StringList            `json:"host"`
+	Path               string                 `json:"path"`
+	ReadIdleTimeout    int32                  `json:"read_idle_timeout"`
+	HealthCheckTimeout int32                  `json:"health_check_timeout"`
+	Method             string                 `json:"method"`
+	Headers            map[string]*StringList `json:"headers"`
+}
+
+// Build implements Buildable.
+func (c *HTTPConfig) Build() (proto.Message, error) {
+	if c.ReadIdleTimeout <= 0 {
+		c.ReadIdleTimeout = 0
+	}
+	if c.HealthCheckTimeout <= 0 {
+		c.HealthCheckTimeout = 0
+	}
+	config := &http.Config{
+		Path:               c.Path,
+		IdleTimeout:        c.ReadIdleTimeout,
+		HealthCheckTimeout: c.HealthCheckTimeout,
+	}
+	if c.Host != nil {
+		config.Host = []string(*c.Host)
+	}
+	if c.Method != "" {
+		config.Method = c.Method
+	}
+	if len(c.Headers) > 0 {
+		config.Header = make([]*httpheader.Header, 0, len(c.Headers))
+		headerNames := sortMapKeys(c.Headers)
+		for _, key := range headerNames {
+			value := c.Headers[key]
+			if value == nil {
+				return nil, newError("empty HTTP header value: " + key).AtError()
+			}
+			config.Header = append(config.Header, &httpheader.Header{
+				Name:  key,
+				Value: append([]string(nil), (*value)...),
+			})
+		}
+	}
+	return config, nil
+}
+
+type GRPCConfig struct {
+	Authority          string `json:"authority"`
+	ServiceName        string `json:"serviceName"`
+	MultiMode          bool   `json:"multiMode"`
+	IdleTimeout        int32  `json:"idle_timeout"`
+	HealthCheckTimeout int32  `json:"health_check_timeout"`
+	UserAgent          string `json:"user_agent"`
+}
+
+// Build implements Buildable.
+func (g *GRPCConfig) Build() (proto.Message, error) {
+	if g.IdleTimeout <= 0 {
+		g.IdleTimeout = 0
+	}
+	if g.HealthCheckTimeout <= 0 {
+		g.HealthCheckTimeout = 0
+	}
+	return &grpc.Config{
+		Host:               g.Authority,
+		ServiceName:        g.ServiceName,
+		MultiMode:          g.MultiMode,
+		IdleTimeout:        g.IdleTimeout,
+		HealthCheckTimeout: g.HealthCheckTimeout,
+		UserAgent:          g.UserAgent,
+	}, nil
+}
+
+type QUICConfig struct {
+	Header          json.RawMessage `json:"header"`
+	KeepAlivePeriod uint32          `json:"keepAlivePeriod"`
+	MaxIdleTimeout  uint32          `json:"maxIdleTimeout"`
+}
+
+// Build implements Buildable.
+func (c *QUICConfig) Build() (proto.Message, error) {
+	config := &quic.Config{
+		KeepAlivePeriod: c.KeepAlivePeriod,
+		MaxIdleTimeout:  c.MaxIdleTimeout,
+	}
+
+	if len(c.Header) > 0 {
+		headerConfig, _, err := kcpHeaderLoader.Load(c.Header)
+		if err != nil {
+			return nil, newError("invalid QUIC header config.").Base(err).AtError()
+		}
+		builder, ok := headerConfig.(Buildable)
+		if !ok {
+			return nil, newError("unsupported QUIC header config.").AtError()
+		}
+		ts, err := builder.Build()
+		if err != nil {
+			return nil, newError("invalid QUIC header config").Base(err).AtError()
+		}
+		config.Header = serial.ToTypedMessage(ts)
+	}
+
+	return config, nil
+}
+
 func readFileOrString(f string, s []string) ([]byte, error) {
 	if len(f) > 0 {
 		return filesystem.ReadFile(f)
@@ -116,6 +361,46 @@ func (c *TLSCertConfig) Build() (*tls.Certificate, error) {
 	return certificate, nil
 }
 
+// parseFingerprint accepts either the name of a built-in fingerprint, the path
+// of a JSON ClientHello spec (absolute, or prefixed with "file:"), or an inline
+// spec object.
+func parseFingerprint(data json.RawMessage) (string, error) {
+	if len(data) == 0 {
+		return "", nil
+	}
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		var spec bytes.Buffer
+		if err := json.Compact(&spec, data); err != nil {
+			return "", err
+		}
+		name = spec.String()
+	} else if !tls.IsCustomFingerprint(name) {
+		name = strings.ToLower(name)
+	}
+	if name == "" {
+		return "", nil
+	}
+	if tls.IsCustomFingerprint(name) {
+		if _, err := tls.LoadFingerprint(name); err != nil {
+			return "", newError("failed to load fingerprint").Base(err)
+		}
+		return name, nil
+	}
+	if tls.GetFingerprint(name) == nil {
+		return "", newError("unknown fingerprint: ", name)
+	}
+	return name, nil
+}
+
+// TLSClientUser is the user of the client certificates of an identity: their
+// subject common name, or else their first SAN.
+type TLSClientUser struct {
+	Identity string `json:"identity"`
+	Email    string `json:"email"`
+	Level    uint32 `json:"level"`
+}
+
 type TLSConfig struct {
 	Insecure                             bool             `json:"allowInsecure"`
 	Certs                                []*TLSCertConfig `json:"certificates"`
@@ -127,10 +412,14 @@ type TLSConfig struct {
 	MaxVersion                           string           `json:"maxVersion"`
 	CipherSuites                         string           `json:"cipherSuites"`
 	PreferServerCipherSuites             bool             `json:"preferServerCipherSuites"`
-	Fingerprint                          string           `json:"fingerprint"`
+	Fingerprint                          json.RawMessage  `json:"fingerprint"`
 	RejectUnknownSNI                     bool             `json:"rejectUnknownSni"`
 	PinnedPeerCertificateChainSha256     *[]string        `json:"pinnedPeerCertificateChainSha256"`
 	PinnedPeerCertificatePublicKeySha256 *[]string        `json:"pinnedPeerCertificatePublicKeySha256"`
+	ClientAuth                           string           `json:"clientAuth"`
+	ClientUsers                          []*TLSClientUser `json:"clientUsers"`
+	SessionTicketKeyFiles                []string         `json:"sessionTicketKeyFiles"`
+	SessionTicketKeyRotation             uint64           `json:"sessionTicketKeyRotation"`
 }
 
 // Build implements Buildable.
@@ -158,12 +447,62 @@ func (c *TLSConfig) Build() (proto.Message, error) {
 	config.MaxVersion = c.MaxVersion
 	config.CipherSuites = c.CipherSuites
 	config.PreferServerCipherSuites = c.PreferServerCipherSuites
-	config.Fingerprint = strings.ToLower(c.Fingerprint)
-	if config.Fingerprint != "" && tls.GetFingerprint(config.Fingerprint) == nil {
-		return nil, newError(`unknown fingerprint: `, config.Fingerprint)
+	fingerprint, err := parseFingerprint(c.Fingerprint)
+	if err != nil {
+		return nil, newError(`invalid fingerprint`).Base(err)
 	}
+	config.Fingerprint = fingerprint
 	config.RejectUnknownSni = c.RejectUnknownSNI
 
+	switch strings.ToLower(c.ClientAuth) {
+	case "", "none":
+		config.ClientAuth = tls.Config_NO_CLIENT_CERT
+	case "require":
+		config.ClientAuth = tls.Config_REQUIRE_AND_VERIFY_CLIENT_CERT
+	case "verify-if-given":
+		config.ClientAuth = tls.Config_VERIFY_CLIENT_CERT_IF_GIVEN
+	default:
+		return nil, newError(`unknown "clientAuth": `, c.ClientAuth)
+	}
+	if config.ClientAuth != tls.Config_NO_CLIENT_CERT {
+		hasCA := false
+		for _, cert := range config.Certificate {
+			hasCA = hasCA || cert.Usage == tls.Certificate_AUTHORITY_VERIFY
+		}
+		if !hasCA {
+			return nil, newError(`"clientAuth" requires a certificate with "usage": "verify"`)
+		}
+	} else if len(c.ClientUsers) > 0 {
+		return nil, newError(`"clientUsers" requires "clientAuth"`)
+	}
+	identities := make(map[string]bool, len(c.ClientUsers))
+	for _, user := range c.ClientUsers {
+		if user.Identity == "" {
+			return nil, newError(`empty "identity" of client user`)
+		}
+		if identities[user.Identity] {
+			return nil, newError(`duplicate client user "identity": `, user.Identity)
+		}
+		identities[user.Identity] = true
+		config.ClientUser = append(config.ClientUser, &tls.ClientUser{
+			Identity: user.Identity,
+			Email:    user.Email,
+			Level:    user.Level,
+		})
+	}
+
+	for _, file := range c.SessionTicketKeyFiles {
+		content, err := filesystem.ReadFile(file)
+		if err != nil {
+			return nil, newError("failed to read session ticket key file: ", file).Base(err)
+		}
+		if _, err := tls.ParseSessionTicketSecrets(content); err != nil {
+			return nil, newError("invalid session ticket key file: ", file).Base(err)
+		}
+	}
+	config.SessionTicketKeyFiles = c.SessionTicketKeyFiles
+	config.SessionTicketKeyRotation = c.SessionTicketKeyRotation
+
 	if c.PinnedPeerCertificateChainSha256 != nil {
 		config.PinnedPeerCertificateChainSha256 = [][]byte{}
 		for _, v := range *c.PinnedPeerCertificateChainSha256 {
@@ -189,6 +528,129 @@ func (c *TLSConfig) Build() (proto.Message, error) {
 	return config, nil
 }
 
+type REALITYConfig struct {
+	Show        bool            `json:"show"`
+	Dest        json.RawMessage `json:"dest"`
+	Type        string          `json:"type"`
+	Xver        uint64          `json:"xver"`
+	ServerNames []string        `json:"serverNames"`
+	PrivateKey  string          `json:"privateKey"`
+	MaxTimeDiff uint64          `json:"maxTimeDiff"`
+	ShortIds    []string        `json:"shortIds"`
+
+	Fingerprint json.RawMessage `json:"fingerprint"`
+	ServerName  string          `json:"serverName"`
+	PublicKey   string          `json:"publicKey"`
+	ShortId     string          `json:"shortId"`
+	SpiderX     string          `json:"spiderX"`
+}
+
+// Build implements Buildable.
+func (c *REALITYConfig) Build() (proto.Message, error) {
+	config := new(reality.Config)
+	config.Show = c.Show
+	var err error
+	if c.Dest != nil {
+		var i uint16
+		var s string
+		if err = json.Unmarshal(c.Dest, &i); err == nil {
+			s = strconv.Itoa(int(i))
+		} else {
+			_ = json.Unmarshal(c.Dest, &s)
+		}
+		if c.Type == "" && s != "" {
+			switch s[0] {
+			case '@', '/':
+				c.Type = "unix"
+			default:
+				if _, err = strconv.Atoi(s); err == nil {
+					s = "127.0.0.1:" + s
+				}
+				if _, _, err = net.SplitHostPort(s); err == nil {
+					c.Type = "tcp"
+				}
+			}
+		}
+		if c.Type == "" {
+			return nil, newError(`please fill in a valid value for "dest"`)
+		}
+		if c.Xver > 2 {
+			return nil, newError(`invalid PROXY protocol version, "xver" only accepts 0, 1, 2`)
+		}
+		if len(c.ServerNames) == 0 {
+			return nil, newError(`empty "serverNames"`)
+		}
+		if c.PrivateKey == "" {
+			return nil, newError(`empty "privateKey"`)
+		}
+		if config.PrivateKey, err = base64.RawURLEncoding.DecodeString(c.PrivateKey); err != nil || len(config.PrivateKey) != 32 {
+			return nil, newError(`invalid "privateKey": `, c.PrivateKey)
+		}
+		if len(c.ShortIds) == 0 {
+			return nil, newError(`empty "shortIds"`)
+		}
+		config.ShortIds = make([][]byte, len(c.ShortIds))
+		for i, s := range c.ShortIds {
+			if config.ShortIds[i], err = parseShortId(s); err != nil {
+				return nil, newError(`invalid "shortIds[`, i, `]": `, s).Base(err)
+			}
+		}
+		config.Dest = s
+		config.Type = c.Type
+		config.Xver = c.Xver
+		config.ServerNames = c.ServerNames
+		config.MaxTimeDiff = c.MaxTimeDiff
+	} else {
+		fingerprint, err := parseFingerprint(c.Fingerprint)
+		if err != nil {
+			return nil, newError(`invalid "fingerprint"`).Base(err)
+		}
+		if fingerprint == "" {
+			return nil, newError(`empty "fingerprint"`)
+		}
+		config.Fingerprint = fingerprint
+		if config.Fingerprint == "hellogolang" {
+			return nil, newError(`invalid "fingerprint": `, config.Fingerprint)
+		}
+		if len(c.ServerNames) != 0 {
+			return nil, newError(`non-empty "serverNames", please use "serverName" instead`)
+		}
+		if c.PublicKey == "" {
+			return nil, newError(`empty "publicKey"`)
+		}
+		if config.PublicKey, err = base64.RawURLEncoding.DecodeString(c.PublicKey); err != nil || len(config.PublicKey) != 32 {
+			return nil, newError(`invalid "publicKey": `, c.PublicKey)
+		}
+		if len(c.ShortIds) != 0 {
+			return nil, newError(`non-empty "shortIds", please use "shortId" instead`)
+		}
+		if config.ShortId, err = parseShortId(c.ShortId); err != nil {
+			return nil, newError(`invalid "shortId": `, c.ShortId).Base(err)
+		}
+		if c.SpiderX == "" {
+			c.SpiderX = "/"
+		}
+		if c.SpiderX[0] != '/' {
+			return nil, newError(`invalid "spiderX": `, c.SpiderX)
+		}
+		config.SpiderX = c.SpiderX
+		config.ServerName = c.ServerName
+	}
+	return config, nil
+}
+
+// parseShortId decodes a hex short id of up to 16 digits into 8 bytes.
+func parseShortId(s string) ([]byte, error) {
+	if len(s) > 16 {
+		return nil, newError("short id is longer than 16 hex digits")
+	}
+	shortId := make([]byte, 8)
+	if _, err := hex.Decode(shortId, []byte(s)); err != nil {
+		return nil, err
+	}
+	return shortId, nil
+}
+
 type TransportProtocol string
 
 // Build implements Buildable.
@@ -196,30 +658,68 @@ func (p TransportProtocol) Build() (string, error) {
 	switch strings.ToLower(string(p)) {
 	case "tcp":
 		return "tcp", nil
+	case "kcp", "mkcp":
+		return "mkcp", nil
+	case "ws", "websocket":
+		return "websocket", nil
 	case "h2", "http":
 		return "http", nil
+	case "grpc", "gun":
+		return "grpc", nil
+	case "quic":
+		return "quic", nil
 	default:
 		return "", newError("Config: unknown transport protocol: ", p)
 	}
 }
 
 type SocketConfig struct {
-	Mark                 int32       `json:"mark"`
-	TFO                  interface{} `json:"tcpFastOpen"`
-	TProxy               string      `json:"tproxy"`
-	AcceptProxyProtocol  bool        `json:"acceptProxyProtocol"`
-	DomainStrategy       string      `json:"domainStrategy"`
-	DialerProxy          string      `json:"dialerProxy"`
-	TCPKeepAliveInterval int32       `json:"tcpKeepAliveInterval"`
-	TCPKeepAliveIdle     int32       `json:"tcpKeepAliveIdle"`
-	TCPCongestion        string      `json:"tcpCongestion"`
-	TCPWindowClamp       int32       `json:"tcpWindowClamp"`
-	TCPMaxSeg            int32       `json:"tcpMaxSeg"`
-	TcpNoDelay           bool        `json:"tcpNoDelay"`
-	TCPUserTimeout       int32       `json:"tcpUserTimeout"`
-	V6only               bool        `json:"v6only"`
-	Interface            string      `json:"interface"`
-	TcpMptcp             bool        `json:"tcpMptcp"`
+	Mark                 int32                `json:"mark"`
+	TFO                  interface{}          `json:"tcpFastOpen"`
+	TProxy               string               `json:"tproxy"`
+	AcceptProxyProtocol  bool                 `json:"acceptProxyProtocol"`
+	DomainStrategy       string               `json:"domainStrategy"`
+	DialerProxy          string               `json:"dialerProxy"`
+	TCPKeepAliveInterval int32                `json:"tcpKeepAliveInterval"`
+	TCPKeepAliveIdle     int32                `json:"tcpKeepAliveIdle"`
+	TCPCongestion        string               `json:"tcpCongestion"`
+	TCPWindowClamp       int32                `json:"tcpWindowClamp"`
+	TCPMaxSeg            int32                `json:"tcpMaxSeg"`
+	TcpNoDelay           bool                 `json:"tcpNoDelay"`
+	TCPUserTimeout       int32                `json:"tcpUserTimeout"`
+	V6only               bool                 `json:"v6only"`
+	Interface            string               `json:"interface"`
+	TcpMptcp             bool                 `json:"tcpMptcp"`
+	ProxyProtocol        uint32               `json:"proxyProtocol"`
+	HappyEyeballs        *HappyEyeballsConfig `json:"happyEyeballs"`
+}
+
+type HappyEyeballsConfig struct {
+	TryDelayMs       int64 `json:"tryDelayMs"`
+	PreferIPv4       bool  `json:"preferIPv4"`
+	Interleave       int32 `json:"interleave"`
+	MaxConcurrentTry int32 `json:"maxConcurrentTry"`
+}
+
+// Build implements Buildable.
+func (c *HappyEyeballsConfig) Build() (*internet.HappyEyeballsConfig, error) {
+	// RFC 8305 bounds the delay between connection attempts to 10ms-2s. Zero
+	// selects the defaults.
+	if c.TryDelayMs != 0 && (c.TryDelayMs < 10 || c.TryDelayMs > 2000) {
+		return nil, newError(`"tryDelayMs" must be 0 or between 10 and 2000: `, c.TryDelayMs)
+	}
+	if c.Interleave < 0 {
+		return nil, newError(`negative "interleave": `, c.Interleave)
+	}
+	if c.MaxConcurrentTry < 0 || c.MaxConcurrentTry > 64 {
+		return nil, newError(`"maxConcurrentTry" must be between 0 and 64: `, c.MaxConcurrentTry)
+	}
+	return &internet.HappyEyeballsConfig{
+		TryDelayMs:       uint64(c.TryDelayMs),
+		PreferIpv4:       c.PreferIPv4,
+		Interleave:       uint32(c.Interleave),
+		MaxConcurrentTry: uint32(c.MaxConcurrentTry),
+	}, nil
 }
 
 // Build implements Buildable.
@@ -249,6 +749,18 @@ func (c *SocketConfig) Build() (*internet.SocketConfig, error) {
 		tproxy = internet.SocketConfig_Off
 	}
 
+	if c.ProxyProtocol > 2 {
+		return nil, newError("unsupported proxyProtocol version: ", c.ProxyProtocol)
+	}
+
+	var happyEyeballs *internet.HappyEyeballsConfig
+	if c.HappyEyeballs != nil {
+		var err error
+		if happyEyeballs, err = c.HappyEyeballs.Build(); err != nil {
+			return nil, newError("failed to build happyEyeballs config").Base(err)
+		}
+	}
+
 	dStrategy := internet.DomainStrategy_AS_IS
 	switch strings.ToLower(c.DomainStrategy) {
 	case "useip", "use_ip":
@@ -276,15 +788,24 @@ func (c *SocketConfig) Build() (*internet.SocketConfig, error) {
 		V6Only:               c.V6only,
 		Interface:            c.Interface,
 		TcpMptcp:             c.TcpMptcp,
+		ProxyProtocol:        c.ProxyProtocol,
+		HappyEyeballs:        happyEyeballs,
 	}, nil
 }
 
 type StreamConfig struct {
-	Network        *TransportProtocol `json:"network"`
-	Security       string             `json:"security"`
-	TLSSettings    *TLSConfig         `json:"tlsSettings"`
-	TCPSettings    *TCPConfig         `json:"tcpSettings"`
-	SocketSettings *SocketConfig      `json:"sockopt"`
+	Network         *TransportProtocol `json:"network"`
+	Security        string             `json:"security"`
+	TLSSettings     *TLSConfig         `json:"tlsSettings"`
+	REALITYSettings *REALITYConfig     `json:"realitySettings"`
+	TCPSettings     *TCPConfig         `json:"tcpSettings"`
+	KCPSettings     *KCPConfig         `json:"kcpSettings"`
+	WSSettings      *WebSocketConfig   `json:"wsSettings"`
+	HTTPSettings    *HTTPConfig        `json:"httpSettings"`
+	QUICSettings    *QUICConfig        `json:"quicSettings"`
+	GRPCConfig      *GRPCConfig        `json:"grpcSettings"`
+	GUNConfig       *GRPCConfig        `json:"gunSettings"`
+	SocketSettings  *SocketConfig      `json:"sockopt"`
 }
 
 // Build implements Buildable.
@@ -310,6 +831,23 @@ func (c *StreamConfig) Build() (*internet.StreamConfig, error) {
 		if err != nil {
 			return nil, newError("Failed to build TLS config.").Base(err)
 		}
+		if ts.(*tls.Config).ClientAuth != tls.Config_NO_CLIENT_CERT && config.ProtocolName != "tcp" {
+			return nil, newError(`TLS "clientAuth" only supports TCP for now.`)
+		}
+		tm := serial.ToTypedMessage(ts)
+		config.SecuritySettings = append(config.SecuritySettings, tm)
+		config.SecurityType = tm.Type
+	case "reality":
+		if config.ProtocolName != "tcp" {
+			return nil, newError("REALITY only supports TCP for now.")
+		}
+		if c.REALITYSettings == nil {
+			return nil, newError(`REALITY: Empty "realitySettings".`)
+		}
+		ts, err := c.REALITYSettings.Build()
+		if err != nil {
+			return nil, newError("Failed to build REALITY config.").Base(err)
+		}
 		tm := serial.ToTypedMessage(ts)
 		config.SecuritySettings = append(config.SecuritySettings, tm)
 		config.SecurityType = tm.Type
@@ -328,6 +866,59 @@ func (c *StreamConfig) Build() (*internet.StreamConfig, error) {
 			Settings:     serial.ToTypedMessage(ts),
 		})
 	}
+	if c.KCPSettings != nil {
+		ts, err := c.KCPSettings.Build()
+		if err != nil {
+			return nil, newError("Failed to build mKCP config.").Base(err)
+		}
+		config.TransportSettings = append(config.TransportSettings, &internet.TransportConfig{
+			ProtocolName: "mkcp",
+			Settings:     serial.ToTypedMessage(ts),
+		})
+	}
+	if c.WSSettings != nil {
+		ts, err := c.WSSettings.Build()
+		if err != nil {
+			return nil, newError("Failed to build WebSocket config.").Base(err)
+		}
+		config.TransportSettings = append(config.TransportSettings, &internet.TransportConfig{
+			ProtocolName: "websocket",
+			Settings:     serial.ToTypedMessage(ts),
+		})
+	}
+	if c.HTTPSettings != nil {
+		ts, err := c.HTTPSettings.Build()
+		if err != nil {
+			return nil, newError("Failed to build HTTP config.").Base(err)
+		}
+		config.TransportSettings = append(config.TransportSettings, &internet.TransportConfig{
+			ProtocolName: "http",
+			Settings:     serial.ToTypedMessage(ts),
+		})
+	}
+	if c.QUICSettings != nil {
+		qs, err := c.QUICSettings.Build()
+		if err != nil {
+			return nil, newError("Failed to build QUIC config.").Base(err)
+		}
+		config.TransportSettings = append(config.TransportSettings, &internet.TransportConfig{
+			ProtocolName: "quic",
+			Settings:     serial.ToTypedMessage(qs),
+		})
+	}
+	if c.GRPCConfig == nil {
+		c.GRPCConfig = c.GUNConfig
+	}
+	if c.GRPCConfig != nil {
+		gs, err := c.GRPCConfig.Build()
+		if err != nil {
+			return nil, newError("Failed to build gRPC config.").Base(err)
+		}
+		config.TransportSettings = append(config.TransportSettings, &internet.TransportConfig{
+			ProtocolName: "grpc",
+			Settings:     serial.ToTypedMessage(gs),
+		})
+	}
 
 	if c.SocketSettings != nil {
 		ss, err := c.SocketSettings.Build()
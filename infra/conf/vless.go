@@ -2,11 +2,7 @@ package conf
 
 import (
 	"encoding/json"
-	"runtime"
-	"strconv"
-	"syscall"
 
-	"github.com/xtls/xray-core/common/net"
 	"github.com/xtls/xray-core/common/protocol"
 	"github.com/xtls/xray-core/common/serial"
 	"github.com/xtls/xray-core/common/uuid"
@@ -16,15 +12,6 @@ import (
 	"google.golang.org/protobuf/proto"
 )
 
-type VLessInboundFallback struct {
-	Name string          `json:"name"`
-	Alpn string          `json:"alpn"`
-	Path string          `json:"path"`
-	Type string          `json:"type"`
-	Dest json.RawMessage `json:"dest"`
-	Xver uint64          `json:"xver"`
-}
-
 type VLessInboundConfig struct {
 	Clients    []json.RawMessage       `json:"clients"`
 	Decryption string                  `json:"decryption"`
@@ -75,60 +62,19 @@ func (c *VLessInboundConfig) Build() (proto.Message, error) {
 		return nil, newError(`VLESS settings: please use "fallbacks":[{}] instead of "fallback":{}`)
 	}
 	for _, fb := range c.Fallbacks {
-		var i uint16
-		var s string
-		if err := json.Unmarshal(fb.Dest, &i); err == nil {
-			s = strconv.Itoa(int(i))
-		} else {
-			_ = json.Unmarshal(fb.Dest, &s)
+		typ, dest, err := fb.parse("VLESS")
+		if err != nil {
+			return nil, err
 		}
 		config.Fallbacks = append(config.Fallbacks, &inbound.Fallback{
 			Name: fb.Name,
 			Alpn: fb.Alpn,
 			Path: fb.Path,
-			Type: fb.Type,
-			Dest: s,
+			Type: typ,
+			Dest: dest,
 			Xver: fb.Xver,
 		})
 	}
-	for _, fb := range config.Fallbacks {
-		/*
-			if fb.Alpn == "h2" && fb.Path != "" {
-				return nil, newError(`VLESS fallbacks: "alpn":"h2" doesn't support "path"`)
-			}
-		*/
-		if fb.Path != "" && fb.Path[0] != '/' {
-			return nil, newError(`VLESS fallbacks: "path" must be empty or start with "/"`)
-		}
-		if fb.Type == "" && fb.Dest != "" {
-			if fb.Dest == "serve-ws-none" {
-				fb.Type = "serve"
-			} else {
-				switch fb.Dest[0] {
-				case '@', '/':
-					fb.Type = "unix"
-					if fb.Dest[0] == '@' && len(fb.Dest) > 1 && fb.Dest[1] == '@' && (runtime.GOOS == "linux" || runtime.GOOS == "android") {
-						fullAddr := make([]byte, len(syscall.RawSockaddrUnix{}.Path)) // may need padding to work with haproxy
-						copy(fullAddr, fb.Dest[1:])
-						fb.Dest = string(fullAddr)
-					}
-				default:
-					if _, err := strconv.Atoi(fb.Dest); err == nil {
-						fb.Dest = "127.0.0.1:" + fb.Dest
-					}
-					if _, _, err := net.SplitHostPort(fb.Dest); err == nil {
-						fb.Type = "tcp"
-					}
-				}
-			}
-		}
-		if fb.Type == "" {
-			return nil, newError(`VLESS fallbacks: please fill in a valid value for every "dest"`)
-		}
-		if fb.Xver > 2 {
-			return nil, newError(`VLESS fallbacks: invalid PROXY protocol version, "xver" only accepts 0, 1, 2`)
-		}
-	}
 
 	return config, nil
 }
@@ -8,6 +8,7 @@ import (
 
 	"github.com/xtls/xray-core/app/dispatcher"
 	"github.com/xtls/xray-core/app/proxyman"
+	"github.com/xtls/xray-core/common/net"
 	"github.com/xtls/xray-core/common/serial"
 	core "github.com/xtls/xray-core/core"
 	"github.com/xtls/xray-core/transport/internet"
@@ -15,14 +16,18 @@ import (
 
 var (
 	inboundConfigLoader = NewJSONConfigLoader(ConfigCreatorCache{
-		"http":  func() interface{} { return new(HTTPServerConfig) },
-		"vless": func() interface{} { return new(VLessInboundConfig) },
+		"http":   func() interface{} { return new(HTTPServerConfig) },
+		"trojan": func() interface{} { return new(TrojanServerConfig) },
+		"vless":  func() interface{} { return new(VLessInboundConfig) },
 	}, "protocol", "settings")
 
 	outboundConfigLoader = NewJSONConfigLoader(ConfigCreatorCache{
-		"freedom": func() interface{} { return new(FreedomConfig) },
-		"http":    func() interface{} { return new(HTTPClientConfig) },
-		"vless":   func() interface{} { return new(VLessOutboundConfig) },
+		"blackhole": func() interface{} { return new(BlackholeConfig) },
+		"loopback":  func() interface{} { return &LoopbackConfig{} },
+		"freedom":   func() interface{} { return new(FreedomConfig) },
+		"http":      func() interface{} { return new(HTTPClientConfig) },
+		"trojan":    func() interface{} { return new(TrojanClientConfig) },
+		"vless":     func() interface{} { return new(VLessOutboundConfig) },
 	}, "protocol", "settings")
 
 	ctllog = log.New(os.Stderr, "xctl> ", 0)
@@ -182,13 +187,14 @@ func (c *InboundDetourConfig) Build() (*core.InboundHandlerConfig, error) {
 }
 
 type OutboundDetourConfig struct {
-	Protocol      string           `json:"protocol"`
-	SendThrough   *Address         `json:"sendThrough"`
-	Tag           string           `json:"tag"`
-	Settings      *json.RawMessage `json:"settings"`
-	StreamSetting *StreamConfig    `json:"streamSettings"`
-	ProxySettings *ProxyConfig     `json:"proxySettings"`
-	MuxSettings   *MuxConfig       `json:"mux"`
+	Protocol            string           `json:"protocol"`
+	SendThrough         *StringList      `json:"sendThrough"`
+	SendThroughStrategy string           `json:"sendThroughStrategy"`
+	Tag                 string           `json:"tag"`
+	Settings            *json.RawMessage `json:"settings"`
+	StreamSetting       *StreamConfig    `json:"streamSettings"`
+	ProxySettings       *ProxyConfig     `json:"proxySettings"`
+	MuxSettings         *MuxConfig       `json:"mux"`
 }
 
 func (c *OutboundDetourConfig) checkChainProxyConfig() error {
@@ -201,6 +207,51 @@ func (c *OutboundDetourConfig) checkChainProxyConfig() error {
 	return nil
 }
 
+// buildSendThrough sets the source address of senderSettings. sendThrough
+// is an IP, a list of IPs and CIDRs to pick from, or "origin".
+func (c *OutboundDetourConfig) buildSendThrough(senderSettings *proxyman.SenderConfig) error {
+	switch strings.ToLower(c.SendThroughStrategy) {
+	case "", "random":
+		senderSettings.ViaStrategy = proxyman.SenderConfig_Random
+	case "destination":
+		senderSettings.ViaStrategy = proxyman.SenderConfig_Destination
+	case "user":
+		senderSettings.ViaStrategy = proxyman.SenderConfig_User
+	case "origin":
+		senderSettings.ViaStrategy = proxyman.SenderConfig_Origin
+	default:
+		return newError("unknown sendThroughStrategy: ", c.SendThroughStrategy)
+	}
+
+	list := make([]string, len(*c.SendThrough))
+	for i, s := range *c.SendThrough {
+		list[i] = expandEnv(strings.TrimSpace(s))
+	}
+	if len(list) == 1 && strings.EqualFold(list[0], "origin") {
+		senderSettings.ViaStrategy = proxyman.SenderConfig_Origin
+		return nil
+	}
+	if len(list) == 1 && !strings.Contains(list[0], "/") && senderSettings.ViaStrategy == proxyman.SenderConfig_Random {
+		address := net.ParseAddress(list[0])
+		if address.Family().IsDomain() {
+			return newError("unable to send through: " + address.String())
+		}
+		senderSettings.Via = net.NewIPOrDomain(address)
+		return nil
+	}
+	for _, s := range list {
+		if strings.Contains(s, "/") {
+			if _, _, err := net.ParseCIDR(s); err != nil {
+				return newError("unable to send through: ", s).Base(err)
+			}
+		} else if net.ParseIP(s) == nil {
+			return newError("unable to send through: ", s)
+		}
+		senderSettings.ViaPool = append(senderSettings.ViaPool, s)
+	}
+	return nil
+}
+
 // Build implements Buildable.
 func (c *OutboundDetourConfig) Build() (*core.OutboundHandlerConfig, error) {
 	senderSettings := &proxyman.SenderConfig{}
@@ -209,11 +260,9 @@ func (c *OutboundDetourConfig) Build() (*core.OutboundHandlerConfig, error) {
 	}
 
 	if c.SendThrough != nil {
-		address := c.SendThrough
-		if address.Family().IsDomain() {
-			return nil, newError("unable to send through: " + address.String())
+		if err := c.buildSendThrough(senderSettings); err != nil {
+			return nil, err
 		}
-		senderSettings.Via = address.Build()
 	}
 
 	if c.StreamSetting != nil {
@@ -299,6 +348,7 @@ type Config struct {
 	InboundConfigs  []InboundDetourConfig  `json:"inbounds"`
 	OutboundConfigs []OutboundDetourConfig `json:"outbounds"`
 	Transport       *TransportConfig       `json:"transport"`
+	Reverse         *ReverseConfig         `json:"reverse"`
 	// Policy          *PolicyConfig          `json:"policy"`
 	Observatory *ObservatoryConfig `json:"observatory"`
 }
@@ -338,6 +388,9 @@ func (c *Config) Override(o *Config, fn string) {
 	if o.Transport != nil {
 		c.Transport = o.Transport
 	}
+	if o.Reverse != nil {
+		c.Reverse = o.Reverse
+	}
 	if o.Observatory != nil {
 		c.Observatory = o.Observatory
 	}
@@ -399,7 +452,24 @@ func applyTransportConfig(s *StreamConfig, t *TransportConfig) {
 	if s.TCPSettings == nil {
 		s.TCPSettings = t.TCPConfig
 	}
-
+	if s.KCPSettings == nil {
+		s.KCPSettings = t.KCPConfig
+	}
+	if s.WSSettings == nil {
+		s.WSSettings = t.WSConfig
+	}
+	if s.HTTPSettings == nil {
+		s.HTTPSettings = t.HTTPConfig
+	}
+	if s.QUICSettings == nil {
+		s.QUICSettings = t.QUICConfig
+	}
+	if s.GRPCConfig == nil {
+		s.GRPCConfig = t.GRPCConfig
+	}
+	if s.GUNConfig == nil {
+		s.GUNConfig = t.GUNConfig
+	}
 }
 
 // Build implements Buildable.
@@ -408,6 +478,9 @@ func (c *Config) Build() (*core.Config, error) {
 		return nil, err
 	}
 
+	release := cacheMMDB()
+	defer release()
+
 	config := &core.Config{
 		App: []*serial.TypedMessage{
 			serial.ToTypedMessage(&dispatcher.Config{}),
@@ -434,6 +507,14 @@ func (c *Config) Build() (*core.Config, error) {
 		config.App = append(config.App, serial.ToTypedMessage(routerConfig))
 	}
 
+	if c.Reverse != nil {
+		r, err := c.Reverse.Build()
+		if err != nil {
+			return nil, err
+		}
+		config.App = append(config.App, serial.ToTypedMessage(r))
+	}
+
 	var inbounds []InboundDetourConfig
 	inbounds = append(inbounds, c.InboundConfigs...)
 	rawInboundConfig := inbounds[0]
@@ -0,0 +1,36 @@
+package conf_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/xtls/xray-core/app/proxyman"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/net"
+	. "github.com/xtls/xray-core/infra/conf"
+)
+
+func TestOutboundSendThrough(t *testing.T) {
+	t.Setenv("XRAY_TEST_SEND_THROUGH", "192.0.2.1")
+	t.Setenv("XRAY_TEST_SEND_THROUGH_POOL", "198.51.100.0/24")
+
+	build := func(config string) *proxyman.SenderConfig {
+		c := new(OutboundDetourConfig)
+		common.Must(json.Unmarshal([]byte(config), c))
+		oc, err := c.Build()
+		common.Must(err)
+		settings, err := oc.SenderSettings.GetInstance()
+		common.Must(err)
+		return settings.(*proxyman.SenderConfig)
+	}
+
+	settings := build(`{"protocol": "freedom", "sendThrough": "env:XRAY_TEST_SEND_THROUGH"}`)
+	if settings.Via.AsAddress() != net.ParseAddress("192.0.2.1") {
+		t.Error("via: ", settings.Via)
+	}
+
+	settings = build(`{"protocol": "freedom", "sendThrough": ["env:XRAY_TEST_SEND_THROUGH", "env:XRAY_TEST_SEND_THROUGH_POOL"]}`)
+	if len(settings.ViaPool) != 2 || settings.ViaPool[0] != "192.0.2.1" || settings.ViaPool[1] != "198.51.100.0/24" {
+		t.Error("via pool: ", settings.ViaPool)
+	}
+}
@@ -1,6 +1,8 @@
 package all
 
 import (
+	"github.com/xtls/xray-core/maincopy/commands/all/route"
+	"github.com/xtls/xray-core/maincopy/commands/all/tls"
 	"github.com/xtls/xray-core/maincopy/commands/base"
 )
 
@@ -9,5 +11,7 @@ import (
 func init() {
 	base.RootCommand.Commands = append(
 		base.RootCommand.Commands,
+		route.CmdRoute,
+		tls.CmdTLS,
 	)
 }
@@ -0,0 +1,19 @@
+package route
+
+import (
+	"github.com/xtls/xray-core/maincopy/commands/base"
+)
+
+//go:generate go run github.com/xtls/xray-core/common/errors/errorgen
+
+// CmdRoute holds all route sub commands
+var CmdRoute = &base.Command{
+	UsageLine: "{{.Exec}} route",
+	Short:     "Routing tools",
+	Long: `{{.Exec}} {{.LongName}} provides tools for routing.
+`,
+
+	Commands: []*base.Command{
+		cmdTest,
+	},
+}
@@ -0,0 +1,176 @@
+package route
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xtls/xray-core/app/router"
+	"github.com/xtls/xray-core/common/cmdarg"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/protocol"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/features/routing"
+	routing_session "github.com/xtls/xray-core/features/routing/session"
+	"github.com/xtls/xray-core/maincopy/commands/base"
+)
+
+var cmdTest = &base.Command{
+	UsageLine: `{{.Exec}} route test [-c config.json] -dest host:port [-network tcp|udp] [-inbound tag] [-source ip[:port]] [-user email] [-protocol name]`,
+	Short:     `Test which rule and outbound a connection is routed to`,
+	Long: `
+Build the router of a config and route a connection described by the
+arguments. For each rule, print whether it matched, or the condition
+that rejected the connection, then print the picked outbound.
+
+Arguments:
+
+	-c, -config <file>
+		Config file. Multiple assign is accepted.
+
+	-format <format>
+		Format of the config files. Default "auto".
+
+	-dest <host:port>
+		Destination of the connection.
+
+	-network <network>
+		Network of the connection, "tcp" or "udp". Default "tcp".
+
+	-inbound <tag>
+		Tag of the inbound the connection comes from.
+
+	-source <ip[:port]>
+		Source address of the connection.
+
+	-user <email>
+		Email of the inbound user.
+
+	-protocol <name>
+		Sniffed protocol of the connection, such as "tls" or "http".
+
+Examples:
+
+	{{.Exec}} {{.LongName}} -c config.json -dest example.com:443 -protocol tls
+	{{.Exec}} {{.LongName}} -c config.json -dest 1.1.1.1:53 -network udp -inbound dns-in
+`,
+}
+
+func init() {
+	cmdTest.Run = executeTest // break init loop
+}
+
+var (
+	testConfigFiles cmdarg.Arg
+	testFormat      = cmdTest.Flag.String("format", "auto", "")
+	testDest        = cmdTest.Flag.String("dest", "", "")
+	testNetwork     = cmdTest.Flag.String("network", "tcp", "")
+	testInbound     = cmdTest.Flag.String("inbound", "", "")
+	testSource      = cmdTest.Flag.String("source", "", "")
+	testUser        = cmdTest.Flag.String("user", "", "")
+	testProtocol    = cmdTest.Flag.String("protocol", "", "")
+
+	_ = func() bool {
+		cmdTest.Flag.Var(&testConfigFiles, "config", "")
+		cmdTest.Flag.Var(&testConfigFiles, "c", "")
+		return true
+	}()
+)
+
+func executeTest(cmd *base.Command, args []string) {
+	if len(testConfigFiles) == 0 {
+		testConfigFiles = cmdarg.Arg{"config.json"}
+	}
+	ctx, err := testRoutingContext()
+	if err != nil {
+		base.Fatalf("%s", err)
+	}
+
+	format := core.GetFormatByExtension(*testFormat)
+	if format == "" {
+		format = "auto"
+	}
+	config, err := core.LoadConfig(format, testConfigFiles)
+	if err != nil {
+		base.Fatalf("failed to load config files [%s]: %s", testConfigFiles.String(), err)
+	}
+	server, err := core.New(config)
+	if err != nil {
+		base.Fatalf("failed to create server: %s", err)
+	}
+	r, ok := server.GetFeature(routing.RouterType()).(*router.Router)
+	if !ok {
+		base.Fatalf("no router in config")
+	}
+
+	explanation, err := r.Explain(ctx)
+	for _, trace := range explanation.Traces {
+		fmt.Println(formatTrace(trace))
+	}
+	switch {
+	case err != nil:
+		base.Fatalf("failed to pick outbound: %s", err)
+	case explanation.Matched == nil:
+		fmt.Println("no rule matched, routed to the default outbound")
+	default:
+		fmt.Println("outbound:", explanation.OutboundTag)
+	}
+}
+
+func testRoutingContext() (routing.Context, error) {
+	if *testDest == "" {
+		return nil, newError("-dest is required")
+	}
+	network := net.Network_TCP
+	switch strings.ToLower(*testNetwork) {
+	case "tcp":
+	case "udp":
+		network = net.Network_UDP
+	default:
+		return nil, newError("unknown network: ", *testNetwork)
+	}
+	dest, err := net.ParseDestination(strings.ToLower(*testNetwork) + ":" + *testDest)
+	if err != nil {
+		return nil, newError("invalid destination: ", *testDest).Base(err)
+	}
+	dest.Network = network
+
+	ctx := &routing_session.Context{
+		Inbound:  &session.Inbound{Tag: *testInbound},
+		Outbound: &session.Outbound{Target: dest},
+		Content:  &session.Content{Protocol: *testProtocol},
+	}
+	if *testSource != "" {
+		source, err := net.ParseDestination(strings.ToLower(*testNetwork) + ":" + *testSource)
+		if err != nil {
+			source = net.Destination{Network: network, Address: net.ParseAddress(*testSource)}
+		}
+		if !source.Address.Family().IsIP() {
+			return nil, newError("invalid source: ", *testSource)
+		}
+		ctx.Inbound.Source = source
+	}
+	if *testUser != "" {
+		ctx.Inbound.User = &protocol.MemoryUser{Email: *testUser}
+	}
+	return ctx, nil
+}
+
+func formatTrace(trace *router.RuleTrace) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "rule #%d", trace.Index)
+	if trace.RuleTag != "" {
+		fmt.Fprintf(&b, " [%s]", trace.RuleTag)
+	}
+	if trace.BalancerTag != "" {
+		fmt.Fprintf(&b, " -> balancer %s", trace.BalancerTag)
+	} else {
+		fmt.Fprintf(&b, " -> %s", trace.OutboundTag)
+	}
+	if trace.Matched {
+		b.WriteString(": matched")
+	} else {
+		fmt.Fprintf(&b, ": rejected by %s", trace.RejectedBy)
+	}
+	return b.String()
+}
@@ -0,0 +1,99 @@
+package tls
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	utls "github.com/refraction-networking/utls"
+	"github.com/xtls/xray-core/maincopy/commands/base"
+	. "github.com/xtls/xray-core/transport/internet/tls"
+)
+
+var cmdCapture = &base.Command{
+	UsageLine: `{{.Exec}} tls capture [-pcap file] [-hex "ClientHello"] [-o file]`,
+	Short:     `Capture a ClientHello spec for "fingerprint"`,
+	Long: `
+Capture a uTLS ClientHello spec from the first ClientHello found in a pcap
+file, or from a raw ClientHello in hex. The spec is printed as JSON, which
+can be saved to a file and referenced by its path, or inlined, in the
+"fingerprint" of tlsSettings and realitySettings.
+
+Arguments:
+
+	-pcap <file>
+		Read the ClientHello from a capture in libpcap format.
+
+	-hex <hex>
+		The ClientHello in hex, with or without the TLS record header.
+
+	-o <file>
+		Write the spec to a file instead of stdout.
+
+Examples:
+
+	{{.Exec}} {{.LongName}} -pcap chrome.pcap -o chrome.json
+	{{.Exec}} {{.LongName}} -hex 1603010200010001fc0303...
+`,
+}
+
+func init() {
+	cmdCapture.Run = executeCapture // break init loop
+}
+
+var (
+	capturePcap   = cmdCapture.Flag.String("pcap", "", "")
+	captureHex    = cmdCapture.Flag.String("hex", "", "")
+	captureOutput = cmdCapture.Flag.String("o", "", "")
+)
+
+func executeCapture(cmd *base.Command, args []string) {
+	var raw []byte
+	var err error
+	switch {
+	case len(*capturePcap) > 0:
+		raw, err = readPcapClientHello(*capturePcap)
+	case len(*captureHex) > 0:
+		raw, err = decodeClientHello(*captureHex)
+	default:
+		base.Fatalf("either -pcap or -hex is required")
+	}
+	if err != nil {
+		base.Fatalf("failed to read ClientHello: %s", err)
+	}
+
+	spec, err := (&utls.Fingerprinter{AllowBluntMimicry: true}).RawClientHello(raw)
+	if err != nil {
+		base.Fatalf("failed to parse ClientHello: %s", err)
+	}
+	specJSON, err := MarshalClientHelloSpec(spec)
+	if err != nil {
+		base.Fatalf("failed to encode ClientHello spec: %s", err)
+	}
+
+	if len(*captureOutput) == 0 {
+		fmt.Println(string(specJSON))
+		return
+	}
+	if err := os.WriteFile(*captureOutput, append(specJSON, '\n'), 0o644); err != nil {
+		base.Fatalf("failed to write %s: %s", *captureOutput, err)
+	}
+}
+
+// decodeClientHello decodes a hex dump of a ClientHello, adding the TLS
+// record header if only the handshake message is given.
+func decodeClientHello(s string) ([]byte, error) {
+	s = strings.NewReplacer(" ", "", "\n", "", "\t", "", ":", "", "0x", "").Replace(s)
+	raw, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) > 0 && raw[0] == handshakeTypeClientHello {
+		raw = append([]byte{recordTypeHandshake, 0x03, 0x01, byte(len(raw) >> 8), byte(len(raw))}, raw...)
+	}
+	if len(raw) < 9 || raw[0] != recordTypeHandshake || raw[5] != handshakeTypeClientHello {
+		return nil, newError("not a ClientHello")
+	}
+	return raw, nil
+}
@@ -0,0 +1,219 @@
+package tls
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"strconv"
+)
+
+const (
+	recordTypeHandshake      = 0x16
+	handshakeTypeClientHello = 0x01
+)
+
+// Link-layer header types, see https://www.tcpdump.org/linktypes.html
+const (
+	linkTypeNull     = 0
+	linkTypeEthernet = 1
+	linkTypeRaw      = 101
+	linkTypeLoop     = 108
+	linkTypeLinuxSLL = 113
+	linkTypeIPv4     = 228
+	linkTypeIPv6     = 229
+	linkTypeSLL2     = 276
+)
+
+// tcpStream keeps the segments of a flow, which may arrive out of order, until
+// the start of a ClientHello is seen.
+type tcpStream struct {
+	started  bool
+	seq      uint32
+	segments map[uint32][]byte
+}
+
+// readPcapClientHello returns the first complete ClientHello sent over TCP in
+// a libpcap file, as a single TLS record.
+func readPcapClientHello(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	reader := bufio.NewReader(file)
+
+	header := make([]byte, 24)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return nil, newError("failed to read pcap header").Base(err)
+	}
+	var order binary.ByteOrder
+	switch magic := binary.LittleEndian.Uint32(header); magic {
+	case 0xa1b2c3d4, 0xa1b23c4d:
+		order = binary.LittleEndian
+	case 0xd4c3b2a1, 0x4d3cb2a1:
+		order = binary.BigEndian
+	case 0x0a0d0d0a:
+		return nil, newError("pcapng is not supported, convert it with: editcap -F pcap")
+	default:
+		return nil, newError("not a pcap file")
+	}
+	linkType := order.Uint32(header[20:]) & 0x0fffffff
+
+	streams := make(map[string]*tcpStream)
+	record := make([]byte, 16)
+	for {
+		if _, err := io.ReadFull(reader, record); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return nil, newError("no ClientHello found")
+			}
+			return nil, err
+		}
+		length := order.Uint32(record[8:])
+		if length > 1<<18 {
+			return nil, newError("invalid packet length ", length)
+		}
+		packet := make([]byte, length)
+		if _, err := io.ReadFull(reader, packet); err != nil {
+			return nil, newError("truncated packet").Base(err)
+		}
+
+		flow, seq, payload := parsePacket(linkType, packet)
+		if len(payload) == 0 {
+			continue
+		}
+		stream := streams[flow]
+		if stream == nil {
+			stream = &tcpStream{segments: make(map[uint32][]byte)}
+			streams[flow] = stream
+		}
+		stream.segments[seq] = payload
+		if !stream.started {
+			if len(payload) < 6 || payload[0] != recordTypeHandshake || payload[5] != handshakeTypeClientHello {
+				continue
+			}
+			stream.seq, stream.started = seq, true
+		}
+		if hello := stream.clientHello(); hello != nil {
+			return hello, nil
+		}
+	}
+}
+
+// clientHello reassembles the stream and returns the ClientHello once all of
+// its records have been seen.
+func (s *tcpStream) clientHello() []byte {
+	var data []byte
+	for {
+		segment, found := s.segments[s.seq+uint32(len(data))]
+		if !found {
+			break
+		}
+		data = append(data, segment...)
+	}
+
+	var message []byte
+	for len(data) >= 5 && data[0] == recordTypeHandshake {
+		end := 5 + int(binary.BigEndian.Uint16(data[3:]))
+		if len(data) < end {
+			return nil
+		}
+		message = append(message, data[5:end]...)
+		data = data[end:]
+		if len(message) >= 4 {
+			if length := 4 + (int(message[1])<<16 | int(message[2])<<8 | int(message[3])); len(message) >= length {
+				message = message[:length]
+				return append([]byte{recordTypeHandshake, 0x03, 0x01, byte(length >> 8), byte(length)}, message...)
+			}
+		}
+	}
+	return nil
+}
+
+// parsePacket returns the flow, sequence number and payload of a TCP segment.
+func parsePacket(linkType uint32, packet []byte) (flow string, seq uint32, payload []byte) {
+	var etherType uint16
+	switch linkType {
+	case linkTypeEthernet:
+		if len(packet) < 14 {
+			return
+		}
+		etherType = binary.BigEndian.Uint16(packet[12:])
+		packet = packet[14:]
+		for etherType == 0x8100 || etherType == 0x88a8 {
+			if len(packet) < 4 {
+				return
+			}
+			etherType = binary.BigEndian.Uint16(packet[2:])
+			packet = packet[4:]
+		}
+	case linkTypeLinuxSLL:
+		if len(packet) < 16 {
+			return
+		}
+		etherType = binary.BigEndian.Uint16(packet[14:])
+		packet = packet[16:]
+	case linkTypeSLL2:
+		if len(packet) < 20 {
+			return
+		}
+		etherType = binary.BigEndian.Uint16(packet)
+		packet = packet[20:]
+	case linkTypeNull, linkTypeLoop:
+		if len(packet) < 4 {
+			return
+		}
+		packet = packet[4:]
+	case linkTypeRaw, linkTypeIPv4, linkTypeIPv6:
+	default:
+		return
+	}
+	if etherType == 0 && len(packet) > 0 {
+		switch packet[0] >> 4 {
+		case 4:
+			etherType = 0x0800
+		case 6:
+			etherType = 0x86dd
+		}
+	}
+
+	var src, dst net.IP
+	switch etherType {
+	case 0x0800:
+		if len(packet) < 20 || packet[9] != 6 || binary.BigEndian.Uint16(packet[6:])&0x1fff != 0 {
+			return
+		}
+		headerLength := int(packet[0]&0x0f) * 4
+		totalLength := int(binary.BigEndian.Uint16(packet[2:]))
+		if headerLength < 20 || totalLength < headerLength || len(packet) < totalLength {
+			return
+		}
+		src, dst = net.IP(packet[12:16]), net.IP(packet[16:20])
+		packet = packet[headerLength:totalLength]
+	case 0x86dd:
+		if len(packet) < 40 || packet[6] != 6 {
+			return
+		}
+		payloadLength := int(binary.BigEndian.Uint16(packet[4:]))
+		if len(packet) < 40+payloadLength {
+			return
+		}
+		src, dst = net.IP(packet[8:24]), net.IP(packet[24:40])
+		packet = packet[40 : 40+payloadLength]
+	default:
+		return
+	}
+
+	if len(packet) < 20 {
+		return
+	}
+	offset := int(packet[12]>>4) * 4
+	if offset < 20 || len(packet) < offset {
+		return
+	}
+	srcPort := strconv.Itoa(int(binary.BigEndian.Uint16(packet)))
+	dstPort := strconv.Itoa(int(binary.BigEndian.Uint16(packet[2:])))
+	flow = net.JoinHostPort(src.String(), srcPort) + ">" + net.JoinHostPort(dst.String(), dstPort)
+	return flow, binary.BigEndian.Uint32(packet[4:]), packet[offset:]
+}
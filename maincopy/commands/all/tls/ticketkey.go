@@ -0,0 +1,62 @@
+package tls
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+
+	"github.com/xtls/xray-core/maincopy/commands/base"
+	. "github.com/xtls/xray-core/transport/internet/tls"
+)
+
+var cmdTicketKey = &base.Command{
+	UsageLine: `{{.Exec}} tls ticketkey [-o file]`,
+	Short:     `Generate a secret for "sessionTicketKeyFiles"`,
+	Long: `
+Generate a random secret for the session ticket keys of TLS servers. Servers
+that share the file of secrets can resume the sessions of each other. The
+keys are derived from the secrets and rotated every
+"sessionTicketKeyRotation" seconds.
+
+The first secret in the files encrypts new tickets, the others only decrypt,
+so to replace a secret, add the new one at the top and remove the old one
+after a rotation period.
+
+Arguments:
+
+	-o <file>
+		Add the secret to the top of the file instead of printing it.
+
+Examples:
+
+	{{.Exec}} {{.LongName}}
+	{{.Exec}} {{.LongName}} -o /etc/xray/ticket.key
+`,
+}
+
+func init() {
+	cmdTicketKey.Run = executeTicketKey // break init loop
+}
+
+var ticketKeyOutput = cmdTicketKey.Flag.String("o", "", "")
+
+func executeTicketKey(cmd *base.Command, args []string) {
+	secret := make([]byte, SessionTicketSecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		base.Fatalf("failed to generate secret: %s", err)
+	}
+	line := base64.StdEncoding.EncodeToString(secret)
+
+	if len(*ticketKeyOutput) == 0 {
+		fmt.Println(line)
+		return
+	}
+	content, err := os.ReadFile(*ticketKeyOutput)
+	if err != nil && !os.IsNotExist(err) {
+		base.Fatalf("failed to read %s: %s", *ticketKeyOutput, err)
+	}
+	if err := os.WriteFile(*ticketKeyOutput, append([]byte(line+"\n"), content...), 0o600); err != nil {
+		base.Fatalf("failed to write %s: %s", *ticketKeyOutput, err)
+	}
+}
@@ -0,0 +1,20 @@
+package tls
+
+import (
+	"github.com/xtls/xray-core/maincopy/commands/base"
+)
+
+//go:generate go run github.com/xtls/xray-core/common/errors/errorgen
+
+// CmdTLS holds all tls sub commands
+var CmdTLS = &base.Command{
+	UsageLine: "{{.Exec}} tls",
+	Short:     "TLS tools",
+	Long: `{{.Exec}} {{.LongName}} provides tools for TLS.
+`,
+
+	Commands: []*base.Command{
+		cmdCapture,
+		cmdTicketKey,
+	},
+}
@@ -10,6 +10,7 @@ import (
 
 	// Other optional features.
 	_ "github.com/xtls/xray-core/app/log"
+	_ "github.com/xtls/xray-core/app/reverse"
 
 	_ "github.com/xtls/xray-core/app/router"
 
@@ -20,15 +21,21 @@ import (
 	// _ "github.com/xtls/xray-core/app/observatory"
 
 	// Inbound and outbound proxies.
+	_ "github.com/xtls/xray-core/proxy/blackhole"
 	_ "github.com/xtls/xray-core/proxy/freedom"
 	_ "github.com/xtls/xray-core/proxy/http"
+	_ "github.com/xtls/xray-core/proxy/loopback"
+	_ "github.com/xtls/xray-core/proxy/trojan"
 	_ "github.com/xtls/xray-core/proxy/vless/inbound"
 	_ "github.com/xtls/xray-core/proxy/vless/outbound"
 
-	// _ "github.com/xtls/xray-core/transport/internet/http"
-
+	_ "github.com/xtls/xray-core/transport/internet/grpc"
+	_ "github.com/xtls/xray-core/transport/internet/http"
+	_ "github.com/xtls/xray-core/transport/internet/kcp"
+	_ "github.com/xtls/xray-core/transport/internet/quic"
 	_ "github.com/xtls/xray-core/transport/internet/tcp"
 	_ "github.com/xtls/xray-core/transport/internet/tls"
+	_ "github.com/xtls/xray-core/transport/internet/websocket"
 
 	// Transport headers
 	_ "github.com/xtls/xray-core/transport/internet/headers/http"
@@ -0,0 +1,300 @@
+// Package fallback implements the name/alpn/path fallback tree shared by inbounds
+// that hand unauthenticated connections over to another server, e.g. VLESS and Trojan.
+package fallback
+
+//go:generate go run github.com/xtls/xray-core/common/errors/errorgen
+
+import (
+	"context"
+	gotls "crypto/tls"
+	"strconv"
+	"strings"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/retry"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/common/signal"
+	"github.com/xtls/xray-core/common/task"
+	"github.com/xtls/xray-core/features/policy"
+	"github.com/xtls/xray-core/transport/internet/reality"
+	"github.com/xtls/xray-core/transport/internet/stat"
+	"github.com/xtls/xray-core/transport/internet/tls"
+)
+
+// Fallback is a single fallback destination. It is implemented by the Fallback
+// messages of the inbounds' protobuf configs.
+type Fallback interface {
+	GetName() string
+	GetAlpn() string
+	GetPath() string
+	GetType() string
+	GetDest() string
+	GetXver() uint64
+}
+
+// Tree indexes fallbacks by SNI name, then by ALPN, then by HTTP path.
+type Tree map[string]map[string]map[string]Fallback
+
+// NewTree builds a Tree from the given fallbacks. Entries with empty name, alpn or
+// path act as defaults and are propagated to the more specific branches.
+func NewTree(fallbacks []Fallback) Tree {
+	t := make(Tree)
+	for _, fb := range fallbacks {
+		if t[fb.GetName()] == nil {
+			t[fb.GetName()] = make(map[string]map[string]Fallback)
+		}
+		if t[fb.GetName()][fb.GetAlpn()] == nil {
+			t[fb.GetName()][fb.GetAlpn()] = make(map[string]Fallback)
+		}
+		t[fb.GetName()][fb.GetAlpn()][fb.GetPath()] = fb
+	}
+	if t[""] != nil {
+		for name, apfb := range t {
+			if name != "" {
+				for alpn := range t[""] {
+					if apfb[alpn] == nil {
+						apfb[alpn] = make(map[string]Fallback)
+					}
+				}
+			}
+		}
+	}
+	for _, apfb := range t {
+		if apfb[""] != nil {
+			for alpn, pfb := range apfb {
+				if alpn != "" { // && alpn != "h2" {
+					for path, fb := range apfb[""] {
+						if pfb[path] == nil {
+							pfb[path] = fb
+						}
+					}
+				}
+			}
+		}
+	}
+	if t[""] != nil {
+		for name, apfb := range t {
+			if name != "" {
+				for alpn, pfb := range t[""] {
+					for path, fb := range pfb {
+						if apfb[alpn][path] == nil {
+							apfb[alpn][path] = fb
+						}
+					}
+				}
+			}
+		}
+	}
+	return t
+}
+
+// Pick selects the fallback for the given TLS server name and negotiated ALPN.
+// first holds the bytes already read from the client, and is used to find the
+// HTTP request path.
+func (t Tree) Pick(ctx context.Context, name, alpn string, first []byte) (Fallback, error) {
+	sid := session.ExportIDToError(ctx)
+	name = strings.ToLower(name)
+	alpn = strings.ToLower(alpn)
+
+	if len(t) > 1 || t[""] == nil {
+		if name != "" && t[name] == nil {
+			match := ""
+			for n := range t {
+				if n != "" && strings.Contains(name, n) && len(n) > len(match) {
+					match = n
+				}
+			}
+			name = match
+		}
+	}
+
+	if t[name] == nil {
+		name = ""
+	}
+	apfb := t[name]
+	if apfb == nil {
+		return nil, newError(`failed to find the default "name" config`).AtWarning()
+	}
+
+	if apfb[alpn] == nil {
+		alpn = ""
+	}
+	pfb := apfb[alpn]
+	if pfb == nil {
+		return nil, newError(`failed to find the default "alpn" config`).AtWarning()
+	}
+
+	path := ""
+	if len(pfb) > 1 || pfb[""] == nil {
+		if len(first) >= 18 && first[4] != '*' { // not h2c
+			for i := 4; i <= 8; i++ { // 5 -> 9
+				if first[i] == '/' && first[i-1] == ' ' {
+					search := len(first)
+					if search > 64 {
+						search = 64 // up to about 60
+					}
+					for j := i + 1; j < search; j++ {
+						k := first[j]
+						if k == '\r' || k == '\n' { // avoid logging \r or \n
+							break
+						}
+						if k == '?' || k == ' ' {
+							path = string(first[i:j])
+							newError("realPath = " + path).AtInfo().WriteToLog(sid)
+							if pfb[path] == nil {
+								path = ""
+							}
+							break
+						}
+					}
+					break
+				}
+			}
+		}
+	}
+	fb := pfb[path]
+	if fb == nil {
+		return nil, newError(`failed to find the default "path" config`).AtWarning()
+	}
+	return fb, nil
+}
+
+// Serve picks a fallback for connection and relays it there. reader must replay
+// first, followed by the rest of the connection.
+func (t Tree) Serve(ctx context.Context, connection stat.Connection, first *buf.Buffer, reader buf.Reader, sessionPolicy policy.Session) error {
+	sid := session.ExportIDToError(ctx)
+
+	iConn := connection
+	if statConn, ok := iConn.(*stat.CounterConnection); ok {
+		iConn = statConn.Connection
+	}
+
+	name := ""
+	alpn := ""
+	var cs *gotls.ConnectionState
+	if tlsConn, ok := iConn.(*tls.Conn); ok {
+		state := tlsConn.ConnectionState()
+		cs = &state
+	} else if realityConn, ok := iConn.(*reality.Conn); ok {
+		state := realityConn.ConnectionState()
+		cs = &state
+	}
+	if cs != nil {
+		name = cs.ServerName
+		alpn = cs.NegotiatedProtocol
+		newError("realName = " + name).AtInfo().WriteToLog(sid)
+		newError("realAlpn = " + alpn).AtInfo().WriteToLog(sid)
+	}
+
+	fb, err := t.Pick(ctx, name, alpn, first.Bytes())
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	timer := signal.CancelAfterInactivity(ctx, cancel, sessionPolicy.Timeouts.ConnectionIdle)
+	ctx = policy.ContextWithBufferPolicy(ctx, sessionPolicy.Buffer)
+
+	var conn net.Conn
+	if err := retry.ExponentialBackoff(5, 100).On(func() error {
+		var dialer net.Dialer
+		conn, err = dialer.DialContext(ctx, fb.GetType(), fb.GetDest())
+		if err != nil {
+			return err
+		}
+		return nil
+	}); err != nil {
+		return newError("failed to dial to " + fb.GetDest()).Base(err).AtWarning()
+	}
+	defer conn.Close()
+
+	serverReader := buf.NewReader(conn)
+	serverWriter := buf.NewWriter(conn)
+
+	postRequest := func() error {
+		defer timer.SetTimeout(sessionPolicy.Timeouts.DownlinkOnly)
+		if xver := fb.GetXver(); xver != 0 {
+			pro := buf.New()
+			defer pro.Release()
+			WriteProxyHeader(pro, xver, connection.RemoteAddr(), connection.LocalAddr())
+			if err := serverWriter.WriteMultiBuffer(buf.MultiBuffer{pro}); err != nil {
+				return newError("failed to set PROXY protocol v", xver).Base(err).AtWarning()
+			}
+		}
+		if err := buf.Copy(reader, serverWriter, buf.UpdateActivity(timer)); err != nil {
+			return newError("failed to fallback request payload").Base(err).AtInfo()
+		}
+		return nil
+	}
+
+	writer := buf.NewWriter(connection)
+
+	getResponse := func() error {
+		defer timer.SetTimeout(sessionPolicy.Timeouts.UplinkOnly)
+		if err := buf.Copy(serverReader, writer, buf.UpdateActivity(timer)); err != nil {
+			return newError("failed to deliver response payload").Base(err).AtInfo()
+		}
+		return nil
+	}
+
+	if err := task.Run(ctx, task.OnSuccess(postRequest, task.Close(serverWriter)), task.OnSuccess(getResponse, task.Close(writer))); err != nil {
+		common.Interrupt(serverReader)
+		common.Interrupt(serverWriter)
+		return newError("fallback ends").Base(err).AtInfo()
+	}
+	return nil
+}
+
+// WriteProxyHeader writes a PROXY protocol header of version xver (1 or 2) into b,
+// describing a stream from remote to local.
+func WriteProxyHeader(b *buf.Buffer, xver uint64, remote, local net.Addr) {
+	ipType := 4
+	remoteAddr, remotePort, err := net.SplitHostPort(remote.String())
+	if err != nil {
+		ipType = 0
+	}
+	localAddr, localPort, err := net.SplitHostPort(local.String())
+	if err != nil {
+		ipType = 0
+	}
+	if ipType == 4 {
+		for i := 0; i < len(remoteAddr); i++ {
+			if remoteAddr[i] == ':' {
+				ipType = 6
+				break
+			}
+		}
+	}
+	switch xver {
+	case 1:
+		if ipType == 0 {
+			b.Write([]byte("PROXY UNKNOWN\r\n"))
+			break
+		}
+		if ipType == 4 {
+			b.Write([]byte("PROXY TCP4 " + remoteAddr + " " + localAddr + " " + remotePort + " " + localPort + "\r\n"))
+		} else {
+			b.Write([]byte("PROXY TCP6 " + remoteAddr + " " + localAddr + " " + remotePort + " " + localPort + "\r\n"))
+		}
+	case 2:
+		b.Write([]byte("\x0D\x0A\x0D\x0A\x00\x0D\x0A\x51\x55\x49\x54\x0A")) // signature
+		if ipType == 0 {
+			b.Write([]byte("\x20\x00\x00\x00")) // v2 + LOCAL + UNSPEC + UNSPEC + 0 bytes
+			break
+		}
+		if ipType == 4 {
+			b.Write([]byte("\x21\x11\x00\x0C")) // v2 + PROXY + AF_INET + STREAM + 12 bytes
+			b.Write(net.ParseIP(remoteAddr).To4())
+			b.Write(net.ParseIP(localAddr).To4())
+		} else {
+			b.Write([]byte("\x21\x21\x00\x24")) // v2 + PROXY + AF_INET6 + STREAM + 36 bytes
+			b.Write(net.ParseIP(remoteAddr).To16())
+			b.Write(net.ParseIP(localAddr).To16())
+		}
+		p1, _ := strconv.ParseUint(remotePort, 10, 16)
+		p2, _ := strconv.ParseUint(localPort, 10, 16)
+		b.Write([]byte{byte(p1 >> 8), byte(p1), byte(p2 >> 8), byte(p2)})
+	}
+}
@@ -1,5 +1,18 @@
 package freedom
 
+import "github.com/xtls/xray-core/transport/internet"
+
 func (c *Config) useIP() bool {
 	return c.DomainStrategy == Config_USE_IP || c.DomainStrategy == Config_USE_IP4 || c.DomainStrategy == Config_USE_IP6
 }
+
+func (c *Config) domainStrategy() internet.DomainStrategy {
+	switch c.DomainStrategy {
+	case Config_USE_IP4:
+		return internet.DomainStrategy_USE_IP4
+	case Config_USE_IP6:
+		return internet.DomainStrategy_USE_IP6
+	default:
+		return internet.DomainStrategy_USE_IP
+	}
+}
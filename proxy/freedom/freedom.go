@@ -11,6 +11,7 @@ import (
 
 	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/dice"
 	"github.com/xtls/xray-core/common/net"
 	"github.com/xtls/xray-core/common/platform"
 	"github.com/xtls/xray-core/common/retry"
@@ -67,9 +68,32 @@ func (h *Handler) policy() policy.Session {
 	return p
 }
 
+func (h *Handler) resolveIPs(ctx context.Context, domain string, localAddr net.Address) []net.IP {
+	ips, err := internet.LookupIP(ctx, domain, h.config.domainStrategy(), localAddr)
+	if err != nil {
+		newError("failed to get IP address for domain ", domain).Base(err).WriteToLog(session.ExportIDToError(ctx))
+	}
+	return ips
+}
+
 func (h *Handler) resolveIP(ctx context.Context, domain string, localAddr net.Address) net.Address {
+	ips := h.resolveIPs(ctx, domain, localAddr)
+	if len(ips) == 0 {
+		return nil
+	}
+	return net.IPAddress(ips[dice.Roll(len(ips))])
+}
 
-	return nil
+// happyEyeballsConfig returns the Happy Eyeballs settings of the sockopt of
+// dialer, if it has stream settings.
+func happyEyeballsConfig(dialer internet.Dialer) *internet.HappyEyeballsConfig {
+	d, ok := dialer.(interface {
+		StreamSettings() *internet.MemoryStreamConfig
+	})
+	if !ok || d.StreamSettings() == nil {
+		return nil
+	}
+	return d.StreamSettings().SocketSettings.GetHappyEyeballs()
 }
 
 func isValidAddress(addr *net.IPOrDomain) bool {
@@ -113,11 +137,22 @@ func (h *Handler) Process(ctx context.Context, link *transport.Link, dialer inte
 	err := retry.ExponentialBackoff(5, 100).On(func() error {
 		dialDest := destination
 		if h.config.useIP() && dialDest.Address.Family().IsDomain() {
-			ip := h.resolveIP(ctx, dialDest.Address.Domain(), dialer.Address())
-			if ip != nil {
+			ips := h.resolveIPs(ctx, dialDest.Address.Domain(), dialer.Address())
+			if dialDest.Network == net.Network_TCP && len(ips) > 1 {
+				newError("dialing to ", dialDest, " with ", len(ips), " addresses").WriteToLog(session.ExportIDToError(ctx))
+				rawConn, err := internet.DialHappyEyeballs(ctx, ips, dialDest.Port, happyEyeballsConfig(dialer), func(ctx context.Context, dest net.Destination) (net.Conn, error) {
+					return dialer.Dial(ctx, dest)
+				})
+				if err != nil {
+					return err
+				}
+				conn = rawConn
+				return nil
+			}
+			if len(ips) > 0 {
 				dialDest = net.Destination{
 					Network: dialDest.Network,
-					Address: ip,
+					Address: net.IPAddress(ips[dice.Roll(len(ips))]),
 					Port:    dialDest.Port,
 				}
 				newError("dialing to ", dialDest).WriteToLog(session.ExportIDToError(ctx))
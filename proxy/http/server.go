@@ -42,10 +42,10 @@ func NewServer(ctx context.Context, config *ServerConfig) (*Server, error) {
 	return s, nil
 }
 
-func (s *Server) policy() policy.Session {
+func (s *Server) policy(level uint32) policy.Session {
 	config := s.config
-	p := s.policyManager.ForLevel(config.UserLevel)
-	if config.Timeout > 0 && config.UserLevel == 0 {
+	p := s.policyManager.ForLevel(level)
+	if config.Timeout > 0 && level == 0 {
 		p.Timeouts.ConnectionIdle = time.Duration(config.Timeout) * time.Second
 	}
 	return p
@@ -86,14 +86,17 @@ func (s *Server) Process(ctx context.Context, network net.Network, conn stat.Con
 	inbound := session.InboundFromContext(ctx)
 	inbound.Name = "http"
 	inbound.SetCanSpliceCopy(2)
-	inbound.User = &protocol.MemoryUser{
-		Level: s.config.UserLevel,
+	if inbound.User == nil {
+		// Not authenticated by a TLS client certificate.
+		inbound.User = &protocol.MemoryUser{
+			Level: s.config.UserLevel,
+		}
 	}
 
 	reader := bufio.NewReaderSize(readerOnly{conn}, buf.Size)
 
 Start:
-	if err := conn.SetReadDeadline(time.Now().Add(s.policy().Timeouts.Handshake)); err != nil {
+	if err := conn.SetReadDeadline(time.Now().Add(s.policy(inbound.User.Level).Timeouts.Handshake)); err != nil {
 		newError("failed to set read deadline").Base(err).WriteToLog(session.ExportIDToError(ctx))
 	}
 
@@ -163,7 +166,7 @@ func (s *Server) handleConnect(ctx context.Context, _ *http.Request, reader *buf
 		return newError("failed to write back OK response").Base(err)
 	}
 
-	plcy := s.policy()
+	plcy := s.policy(inbound.User.Level)
 	ctx, cancel := context.WithCancel(ctx)
 	timer := signal.CancelAfterInactivity(ctx, cancel, plcy.Timeouts.ConnectionIdle)
 
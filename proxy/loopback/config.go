@@ -0,0 +1,3 @@
+package loopback
+
+//go:generate go run github.com/xtls/xray-core/common/errors/errorgen
@@ -0,0 +1,100 @@
+// Package loopback is an outbound handler that sends connections back to the routing
+// dispatcher under another inbound tag, so that they can be routed a second time.
+package loopback
+
+import (
+	"context"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/retry"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/common/task"
+	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/features/routing"
+	"github.com/xtls/xray-core/transport"
+	"github.com/xtls/xray-core/transport/internet"
+)
+
+type Loopback struct {
+	config             *Config
+	dispatcherInstance routing.Dispatcher
+}
+
+// Process implements proxy.Outbound.
+func (l *Loopback) Process(ctx context.Context, link *transport.Link, _ internet.Dialer) error {
+	outbound := session.OutboundFromContext(ctx)
+	if outbound == nil || !outbound.Target.IsValid() {
+		return newError("target not specified.")
+	}
+	destination := outbound.Target
+
+	newError("opening connection to ", destination).WriteToLog(session.ExportIDToError(ctx))
+
+	input := link.Reader
+	output := link.Writer
+
+	// The second pass gets its own inbound and outbound metadata, so that
+	// the routing decision of the first pass is left untouched.
+	inbound := &session.Inbound{}
+	if original := session.InboundFromContext(ctx); original != nil {
+		*inbound = *original
+	}
+	inbound.Tag = l.config.InboundTag
+	ctx = session.ContextWithInbound(ctx, inbound)
+	ctx = session.ContextWithOutbound(ctx, nil)
+
+	content := new(session.Content)
+	content.SkipDNSResolve = true
+	ctx = session.ContextWithContent(ctx, content)
+
+	var rawLink *transport.Link
+	err := retry.ExponentialBackoff(2, 100).On(func() error {
+		var err error
+		rawLink, err = l.dispatcherInstance.Dispatch(ctx, destination)
+		return err
+	})
+	if err != nil {
+		return newError("failed to open connection to ", destination).Base(err)
+	}
+
+	requestDone := func() error {
+		if err := buf.Copy(input, rawLink.Writer); err != nil {
+			return newError("failed to process request").Base(err)
+		}
+
+		return nil
+	}
+
+	responseDone := func() error {
+		if err := buf.Copy(rawLink.Reader, output); err != nil {
+			return newError("failed to process response").Base(err)
+		}
+
+		return nil
+	}
+
+	if err := task.Run(ctx, task.OnSuccess(requestDone, task.Close(rawLink.Writer)), task.OnSuccess(responseDone, task.Close(output))); err != nil {
+		common.Interrupt(rawLink.Reader)
+		common.Interrupt(rawLink.Writer)
+		return newError("connection ends").Base(err)
+	}
+
+	return nil
+}
+
+func (l *Loopback) init(config *Config, dispatcherInstance routing.Dispatcher) error {
+	l.dispatcherInstance = dispatcherInstance
+	l.config = config
+	return nil
+}
+
+func init() {
+	common.Must(common.RegisterConfig((*Config)(nil), func(ctx context.Context, config interface{}) (interface{}, error) {
+		l := new(Loopback)
+		err := core.RequireFeatures(ctx, func(dispatcherInstance routing.Dispatcher) error {
+			return l.init(config.(*Config), dispatcherInstance)
+		})
+		return l, err
+	}))
+}
@@ -0,0 +1,130 @@
+package trojan_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/protocol"
+	. "github.com/xtls/xray-core/proxy/trojan"
+)
+
+func toAccount(a *Account) protocol.Account {
+	account, err := a.AsAccount()
+	common.Must(err)
+	return account
+}
+
+func TestTCPRequest(t *testing.T) {
+	user := &protocol.MemoryUser{
+		Email: "love@example.com",
+		Account: toAccount(&Account{
+			Password: "password",
+		}),
+	}
+	payload := []byte("test string")
+	data := buf.New()
+	common.Must2(data.Write(payload))
+
+	buffer := buf.New()
+	defer buffer.Release()
+
+	destination := net.Destination{Network: net.Network_TCP, Address: net.LocalHostIP, Port: 1234}
+	writer := &ConnWriter{Writer: buffer, Target: destination, Account: user.Account.(*MemoryAccount)}
+	common.Must(writer.WriteMultiBuffer(buf.MultiBuffer{data}))
+
+	reader := &ConnReader{Reader: buffer}
+	common.Must(reader.ParseHeader())
+
+	if reader.Target != destination {
+		t.Error("destination: ", reader.Target)
+	}
+
+	decodedData, err := reader.ReadMultiBuffer()
+	common.Must(err)
+	if !bytes.Equal(decodedData[0].Bytes(), payload) {
+		t.Error("data: ", decodedData[0].String())
+	}
+}
+
+func TestUDPRequest(t *testing.T) {
+	user := &protocol.MemoryUser{
+		Email: "love@example.com",
+		Account: toAccount(&Account{
+			Password: "password",
+		}),
+	}
+	payload := []byte("test string")
+	data := buf.New()
+	common.Must2(data.Write(payload))
+
+	buffer := buf.New()
+	defer buffer.Release()
+
+	destination := net.Destination{Network: net.Network_UDP, Address: net.LocalHostIP, Port: 1234}
+	writer := &PacketWriter{Writer: &ConnWriter{Writer: buffer, Target: destination, Account: user.Account.(*MemoryAccount)}, Target: destination}
+	common.Must(writer.WriteMultiBuffer(buf.MultiBuffer{data}))
+
+	connReader := &ConnReader{Reader: buffer}
+	common.Must(connReader.ParseHeader())
+
+	packetReader := &PacketReader{Reader: connReader}
+	mb, err := packetReader.ReadMultiBuffer()
+	common.Must(err)
+
+	if mb.IsEmpty() {
+		t.Error("no request data")
+	}
+
+	mb, decoded := buf.SplitFirst(mb)
+	buf.ReleaseMulti(mb)
+
+	if *decoded.UDP != destination {
+		t.Error("destination: ", decoded.UDP)
+	}
+
+	if !bytes.Equal(decoded.Bytes(), payload) {
+		t.Error("data: ", decoded.String())
+	}
+}
+
+func TestUDPPacketDestination(t *testing.T) {
+	buffer := buf.New()
+	defer buffer.Release()
+
+	target := net.UDPDestination(net.LocalHostIP, 53)
+	other := net.UDPDestination(net.DomainAddress("example.com"), 443)
+
+	first := buf.New()
+	common.Must2(first.WriteString("first"))
+	second := buf.New()
+	common.Must2(second.WriteString("second"))
+	second.UDP = &other
+
+	writer := &PacketWriter{Writer: buffer, Target: target}
+	common.Must(writer.WriteMultiBuffer(buf.MultiBuffer{first, second}))
+
+	reader := &PacketReader{Reader: buffer}
+	for _, expected := range []net.Destination{target, other} {
+		mb, err := reader.ReadMultiBuffer()
+		common.Must(err)
+		if *mb[0].UDP != expected {
+			t.Error("destination: ", mb[0].UDP, ", want ", expected)
+		}
+		buf.ReleaseMulti(mb)
+	}
+}
+
+func TestInvalidTCPConn(t *testing.T) {
+	buffer := buf.New()
+	defer buffer.Release()
+
+	common.Must2(buffer.WriteString("GET / HTTP/1.1\r\n"))
+
+	reader := &ConnReader{Reader: buffer}
+	if err := reader.ParseHeader(); err == nil {
+		t.Error("expected error for truncated header")
+	}
+}
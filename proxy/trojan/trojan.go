@@ -0,0 +1,8 @@
+// Package trojan contains the implementation of the Trojan protocol.
+//
+// A Trojan request starts with the hex encoded SHA224 of the user password, so the
+// protocol is normally carried in TLS and shares the front with a web server, to
+// which unrecognized clients are handed over through fallbacks.
+package trojan
+
+//go:generate go run github.com/xtls/xray-core/common/errors/errorgen
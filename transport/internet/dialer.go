@@ -2,7 +2,9 @@ package internet
 
 import (
 	"context"
+	gonet "net"
 
+	"github.com/pires/go-proxyproto"
 	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/common/dice"
 	"github.com/xtls/xray-core/common/net"
@@ -71,8 +73,23 @@ var (
 	obm outbound.Manager
 )
 
-func lookupIP(domain string, strategy DomainStrategy, localAddr net.Address) ([]net.IP, error) {
-	return nil, nil
+// LookupIP resolves domain with the system resolver. The address family is
+// chosen by strategy; USE_IP follows the family of localAddr if it is set.
+func LookupIP(ctx context.Context, domain string, strategy DomainStrategy, localAddr net.Address) ([]net.IP, error) {
+	network := "ip"
+	switch strategy {
+	case DomainStrategy_USE_IP4:
+		network = "ip4"
+	case DomainStrategy_USE_IP6:
+		network = "ip6"
+	case DomainStrategy_USE_IP:
+		if localAddr != nil && localAddr.Family().IsIPv4() {
+			network = "ip4"
+		} else if localAddr != nil && localAddr.Family().IsIPv6() {
+			network = "ip6"
+		}
+	}
+	return gonet.DefaultResolver.LookupIP(ctx, network, domain)
 }
 
 func canLookupIP(ctx context.Context, dst net.Destination, sockopt *SocketConfig) bool {
@@ -113,8 +130,10 @@ func DialSystem(ctx context.Context, dest net.Destination, sockopt *SocketConfig
 		return effectiveSystemDialer.Dial(ctx, src, dest, sockopt)
 	}
 
+	var ips []net.IP
 	if canLookupIP(ctx, dest, sockopt) {
-		ips, err := lookupIP(dest.Address.String(), sockopt.DomainStrategy, src)
+		var err error
+		ips, err = LookupIP(ctx, dest.Address.String(), sockopt.DomainStrategy, src)
 		if err == nil && len(ips) > 0 {
 			dest.Address = net.IPAddress(ips[dice.Roll(len(ips))])
 			newError("replace destination with " + dest.String()).AtInfo().WriteToLog()
@@ -123,14 +142,62 @@ func DialSystem(ctx context.Context, dest net.Destination, sockopt *SocketConfig
 		}
 	}
 
+	var conn net.Conn
 	if obm != nil && len(sockopt.DialerProxy) > 0 {
-		nc := redirect(ctx, dest, sockopt.DialerProxy)
-		if nc != nil {
-			return nc, nil
+		conn = redirect(ctx, dest, sockopt.DialerProxy)
+	}
+	if conn == nil {
+		var err error
+		if dest.Network == net.Network_TCP && len(ips) > 1 {
+			conn, err = DialHappyEyeballs(ctx, ips, dest.Port, sockopt.HappyEyeballs, func(ctx context.Context, dest net.Destination) (net.Conn, error) {
+				return effectiveSystemDialer.Dial(ctx, src, dest, sockopt)
+			})
+		} else {
+			conn, err = effectiveSystemDialer.Dial(ctx, src, dest, sockopt)
+		}
+		if err != nil {
+			return nil, err
 		}
 	}
 
-	return effectiveSystemDialer.Dial(ctx, src, dest, sockopt)
+	if sockopt.ProxyProtocol > 0 && dest.Network == net.Network_TCP {
+		if err := writeProxyHeader(ctx, conn, sockopt.ProxyProtocol); err != nil {
+			conn.Close()
+			return nil, newError("failed to write PROXY protocol header").Base(err)
+		}
+	}
+	return conn, nil
+}
+
+// writeProxyHeader writes a PROXY protocol header describing the inbound
+// connection of ctx, so that the upstream sees the address of the client.
+// The header is sent without addresses if they are unknown.
+func writeProxyHeader(ctx context.Context, conn net.Conn, version uint32) error {
+	var src, dst *net.TCPAddr
+	if inbound := session.InboundFromContext(ctx); inbound != nil && inbound.Source.IsValid() && inbound.Source.Address.Family().IsIP() {
+		if inbound.Gateway.IsValid() && inbound.Gateway.Address.Family().IsIP() {
+			dst = &net.TCPAddr{IP: inbound.Gateway.Address.IP(), Port: int(inbound.Gateway.Port)}
+		}
+		if inbound.Conn != nil {
+			switch addr := inbound.Conn.LocalAddr().(type) {
+			case *net.TCPAddr:
+				dst = addr
+			case *net.UDPAddr:
+				dst = &net.TCPAddr{IP: addr.IP, Port: addr.Port}
+			}
+		}
+		src = &net.TCPAddr{IP: inbound.Source.Address.IP(), Port: int(inbound.Source.Port)}
+		if dst == nil || (src.IP.To4() == nil) != (dst.IP.To4() == nil) {
+			src, dst = nil, nil
+		}
+	}
+
+	header := proxyproto.HeaderProxyFromAddrs(byte(version), nil, nil)
+	if src != nil {
+		header = proxyproto.HeaderProxyFromAddrs(byte(version), src, dst)
+	}
+	_, err := header.WriteTo(conn)
+	return err
 }
 
 func InitSystemDialer(om outbound.Manager) {
@@ -0,0 +1,45 @@
+package internet_test
+
+import (
+	"bufio"
+	"context"
+	"testing"
+
+	"github.com/pires/go-proxyproto"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/transport/internet"
+)
+
+func TestDialWithProxyProtocol(t *testing.T) {
+	ctx := session.ContextWithInbound(context.Background(), &session.Inbound{
+		Source:  net.TCPDestination(net.ParseAddress("1.2.3.4"), 5678),
+		Gateway: net.TCPDestination(net.ParseAddress("10.0.0.1"), 443),
+	})
+
+	for _, version := range []uint32{1, 2} {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		common.Must(err)
+		headers := make(chan *proxyproto.Header, 1)
+		go func() {
+			conn, err := listener.Accept()
+			common.Must(err)
+			defer conn.Close()
+			header, err := proxyproto.Read(bufio.NewReader(conn))
+			if err != nil {
+				t.Error(err)
+			}
+			headers <- header
+		}()
+
+		conn, err := internet.DialSystem(ctx, net.DestinationFromAddr(listener.Addr()), &internet.SocketConfig{ProxyProtocol: version})
+		common.Must(err)
+		header := <-headers
+		if header == nil || header.Version != byte(version) || header.SourceAddr.String() != "1.2.3.4:5678" || header.DestinationAddr.String() != "10.0.0.1:443" {
+			t.Error("unexpected header: ", header)
+		}
+		conn.Close()
+		listener.Close()
+	}
+}
@@ -0,0 +1,39 @@
+package grpc
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/transport/internet"
+)
+
+const protocolName = "grpc"
+
+// getPaths returns the request paths of the Tun and TunMulti streams.
+//
+// A plain service name "name" maps to "/name/Tun" and "/name/TunMulti". A service name
+// starting with "/" is a custom path like "/my/sample/path1|path2", which maps to
+// "/my/sample/path1" and "/my/sample/path2". Without "|", the multi stream path is the
+// first one suffixed with "Multi".
+func (c *Config) getPaths() (string, string) {
+	if !strings.HasPrefix(c.ServiceName, "/") {
+		prefix := "/" + url.PathEscape(c.ServiceName)
+		return prefix + "/Tun", prefix + "/TunMulti"
+	}
+
+	lastIndex := strings.LastIndex(c.ServiceName, "/")
+	prefix := c.ServiceName[:lastIndex]
+	names := strings.Split(c.ServiceName[lastIndex+1:], "|")
+	tun := prefix + "/" + names[0]
+	if len(names) > 1 {
+		return tun, prefix + "/" + names[1]
+	}
+	return tun, tun + "Multi"
+}
+
+func init() {
+	common.Must(internet.RegisterProtocolConfigCreator(protocolName, func() interface{} {
+		return new(Config)
+	}))
+}
@@ -0,0 +1,253 @@
+package grpc
+
+import (
+	"context"
+	gotls "crypto/tls"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/net/cnc"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/transport/internet"
+	"github.com/xtls/xray-core/transport/internet/grpc/encoding"
+	"github.com/xtls/xray-core/transport/internet/stat"
+	"github.com/xtls/xray-core/transport/internet/tls"
+	"golang.org/x/net/http2"
+)
+
+const defaultUserAgent = "grpc-go/1.58.3"
+
+type dialerConf struct {
+	net.Destination
+	*internet.MemoryStreamConfig
+}
+
+var (
+	globalDialerMap    map[dialerConf]*http.Client
+	globalDialerAccess sync.Mutex
+)
+
+// Dial dials a new gRPC stream to the given destination.
+func Dial(ctx context.Context, dest net.Destination, streamSettings *internet.MemoryStreamConfig) (stat.Connection, error) {
+	newError("creating connection to ", dest).WriteToLog(session.ExportIDToError(ctx))
+
+	conn, err := dialgRPC(ctx, dest, streamSettings)
+	if err != nil {
+		return nil, newError("failed to dial gRPC").Base(err)
+	}
+	return stat.Connection(conn), nil
+}
+
+func init() {
+	common.Must(internet.RegisterTransportDialer(protocolName, Dial))
+}
+
+func dialgRPC(ctx context.Context, dest net.Destination, streamSettings *internet.MemoryStreamConfig) (net.Conn, error) {
+	grpcSettings := streamSettings.ProtocolSettings.(*Config)
+	client := getGrpcClient(ctx, dest, streamSettings)
+
+	scheme := "https"
+	if tls.ConfigFromStreamSettings(streamSettings) == nil {
+		scheme = "http"
+	}
+
+	path, multiPath := grpcSettings.getPaths()
+	if grpcSettings.MultiMode {
+		newError("using gRPC multi mode").AtDebug().WriteToLog()
+		path = multiPath
+	}
+
+	userAgent := grpcSettings.UserAgent
+	if userAgent == "" {
+		userAgent = defaultUserAgent
+	}
+
+	preader, pwriter := io.Pipe()
+	request := &http.Request{
+		Method: http.MethodPost,
+		Host:   grpcSettings.Host,
+		Body:   preader,
+		URL: &url.URL{
+			Scheme: scheme,
+			Host:   dest.NetAddr(),
+			Path:   path,
+		},
+		Proto:      "HTTP/2",
+		ProtoMajor: 2,
+		ProtoMinor: 0,
+		Header: http.Header{
+			"Content-Type": {"application/grpc"},
+			"Te":           {"trailers"},
+			"User-Agent":   {userAgent},
+		},
+	}
+
+	body := &responseBody{ready: make(chan struct{})}
+	go func() {
+		response, err := client.Do(request)
+		if err != nil {
+			newError("failed to dial to ", dest).Base(err).AtWarning().WriteToLog(session.ExportIDToError(ctx))
+			body.Close()
+			preader.CloseWithError(err)
+			{
+				// Abandon `client` if `client.Do(request)` failed
+				// See https://github.com/golang/go/issues/30702
+				globalDialerAccess.Lock()
+				if globalDialerMap[dialerConf{dest, streamSettings}] == client {
+					delete(globalDialerMap, dialerConf{dest, streamSettings})
+				}
+				globalDialerAccess.Unlock()
+			}
+			return
+		}
+		if response.StatusCode != http.StatusOK {
+			newError("unexpected status ", response.StatusCode).AtWarning().WriteToLog(session.ExportIDToError(ctx))
+			response.Body.Close()
+			body.Close()
+			preader.Close()
+			return
+		}
+		if status := response.Header.Get("Grpc-Status"); status != "" && status != "0" {
+			newError("unexpected gRPC status ", status, ": ", response.Header.Get("Grpc-Message")).AtWarning().WriteToLog(session.ExportIDToError(ctx))
+			response.Body.Close()
+			body.Close()
+			preader.Close()
+			return
+		}
+		body.set(response.Body)
+	}()
+
+	return cnc.NewConnection(
+		cnc.ConnectionOutputMulti(encoding.NewHunkReader(body, grpcSettings.MultiMode)),
+		cnc.ConnectionInputMulti(encoding.NewHunkWriter(pwriter, grpcSettings.MultiMode)),
+		cnc.ConnectionOnClose(body),
+	), nil
+}
+
+func getGrpcClient(ctx context.Context, dest net.Destination, streamSettings *internet.MemoryStreamConfig) *http.Client {
+	globalDialerAccess.Lock()
+	defer globalDialerAccess.Unlock()
+
+	if globalDialerMap == nil {
+		globalDialerMap = make(map[dialerConf]*http.Client)
+	}
+
+	if client, found := globalDialerMap[dialerConf{dest, streamSettings}]; found {
+		return client
+	}
+
+	grpcSettings := streamSettings.ProtocolSettings.(*Config)
+	tlsConfigs := tls.ConfigFromStreamSettings(streamSettings)
+	sockopt := streamSettings.SocketSettings
+
+	transport := &http2.Transport{
+		DialTLSContext: func(gctx context.Context, _, _ string, tlsConfig *gotls.Config) (net.Conn, error) {
+			gctx = session.ContextWithID(gctx, session.IDFromContext(ctx))
+			gctx = session.ContextWithOutbound(gctx, session.OutboundFromContext(ctx))
+			gctx = session.ContextWithTimeoutOnly(gctx, true)
+
+			pconn, err := internet.DialSystem(gctx, dest, sockopt)
+			if err != nil {
+				return nil, err
+			}
+
+			if tlsConfigs == nil {
+				// h2c, HTTP/2 with prior knowledge over cleartext TCP
+				return pconn, nil
+			}
+
+			var cn tls.Interface
+			if fingerprint := tls.GetFingerprint(tlsConfigs.Fingerprint); fingerprint != nil {
+				uConn, err := tls.UClient(pconn, tlsConfig, fingerprint)
+				if err != nil {
+					pconn.Close()
+					return nil, err
+				}
+				cn = uConn.(*tls.UConn)
+			} else {
+				cn = tls.Client(pconn, tlsConfig).(*tls.Conn)
+			}
+			if err := cn.Handshake(); err != nil {
+				pconn.Close()
+				return nil, err
+			}
+			if !tlsConfig.InsecureSkipVerify {
+				if err := cn.VerifyHostname(tlsConfig.ServerName); err != nil {
+					pconn.Close()
+					return nil, err
+				}
+			}
+			if negotiatedProtocol, _ := cn.NegotiatedProtocol(); negotiatedProtocol != http2.NextProtoTLS {
+				pconn.Close()
+				return nil, newError("unexpected ALPN protocol ", negotiatedProtocol, "; want ", http2.NextProtoTLS)
+			}
+			return cn, nil
+		},
+	}
+
+	if tlsConfigs != nil {
+		transport.TLSClientConfig = tlsConfigs.GetTLSConfig(tls.WithDestination(dest), tls.WithNextProto(http2.NextProtoTLS))
+	} else {
+		transport.AllowHTTP = true
+	}
+
+	if grpcSettings.IdleTimeout > 0 || grpcSettings.HealthCheckTimeout > 0 {
+		transport.ReadIdleTimeout = time.Second * time.Duration(grpcSettings.IdleTimeout)
+		transport.PingTimeout = time.Second * time.Duration(grpcSettings.HealthCheckTimeout)
+	}
+
+	client := &http.Client{
+		Transport: transport,
+	}
+
+	globalDialerMap[dialerConf{dest, streamSettings}] = client
+	return client
+}
+
+// responseBody is the body of a gRPC response which may not have arrived yet.
+type responseBody struct {
+	access sync.Mutex
+	ready  chan struct{}
+	closed bool
+	body   io.ReadCloser
+}
+
+func (r *responseBody) set(body io.ReadCloser) {
+	r.access.Lock()
+	defer r.access.Unlock()
+
+	if r.closed {
+		body.Close()
+		return
+	}
+	r.body = body
+	close(r.ready)
+}
+
+func (r *responseBody) Read(b []byte) (int, error) {
+	<-r.ready
+	if r.body == nil {
+		return 0, io.ErrClosedPipe
+	}
+	return r.body.Read(b)
+}
+
+func (r *responseBody) Close() error {
+	r.access.Lock()
+	defer r.access.Unlock()
+
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	if r.body != nil {
+		return r.body.Close()
+	}
+	close(r.ready)
+	return nil
+}
@@ -0,0 +1,6 @@
+// Package encoding implements the message framing of the gRPC-compatible "gun" tunnel.
+// Tunnelled data is carried in Hunk (or MultiHunk) messages of a bidirectional gRPC
+// stream, so that it can pass through any HTTP/2 reverse proxy that supports gRPC.
+package encoding
+
+//go:generate go run github.com/xtls/xray-core/common/errors/errorgen
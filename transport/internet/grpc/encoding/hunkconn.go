@@ -0,0 +1,131 @@
+package encoding
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/xtls/xray-core/common/buf"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// headerSize is the size of the gRPC message prefix: 1 byte compression flag
+	// followed by 4 bytes message length in big endian.
+	headerSize = 5
+
+	// maxMessageSize is the default limit of received messages in gRPC.
+	maxMessageSize = 4 << 20
+)
+
+// HunkReader reads Hunk or MultiHunk messages from a gRPC stream.
+type HunkReader struct {
+	reader io.Reader
+	multi  bool
+	header [headerSize]byte
+}
+
+// NewHunkReader creates a HunkReader. If multi is true, MultiHunk messages are expected.
+func NewHunkReader(reader io.Reader, multi bool) *HunkReader {
+	return &HunkReader{
+		reader: reader,
+		multi:  multi,
+	}
+}
+
+// ReadMultiBuffer implements buf.Reader.
+func (r *HunkReader) ReadMultiBuffer() (buf.MultiBuffer, error) {
+	if _, err := io.ReadFull(r.reader, r.header[:]); err != nil {
+		return nil, err
+	}
+	if r.header[0] != 0 {
+		return nil, newError("compressed gRPC message is not supported")
+	}
+	size := binary.BigEndian.Uint32(r.header[1:])
+	if size > maxMessageSize {
+		return nil, newError("gRPC message too large: ", size)
+	}
+	message := make([]byte, size)
+	if _, err := io.ReadFull(r.reader, message); err != nil {
+		return nil, err
+	}
+
+	var mb buf.MultiBuffer
+	if r.multi {
+		var hunk MultiHunk
+		if err := proto.Unmarshal(message, &hunk); err != nil {
+			return nil, newError("failed to parse MultiHunk").Base(err)
+		}
+		for _, data := range hunk.Data {
+			mb = buf.MergeBytes(mb, data)
+		}
+	} else {
+		var hunk Hunk
+		if err := proto.Unmarshal(message, &hunk); err != nil {
+			return nil, newError("failed to parse Hunk").Base(err)
+		}
+		mb = buf.MergeBytes(mb, hunk.Data)
+	}
+	return mb, nil
+}
+
+// HunkWriter writes Hunk or MultiHunk messages into a gRPC stream.
+type HunkWriter struct {
+	writer io.Writer
+	multi  bool
+}
+
+// NewHunkWriter creates a HunkWriter. If multi is true, MultiHunk messages are written.
+func NewHunkWriter(writer io.Writer, multi bool) *HunkWriter {
+	return &HunkWriter{
+		writer: writer,
+		multi:  multi,
+	}
+}
+
+func (w *HunkWriter) writeMessage(m proto.Message) error {
+	size := proto.Size(m)
+	frame := make([]byte, headerSize, headerSize+size)
+	binary.BigEndian.PutUint32(frame[1:], uint32(size))
+	frame, err := proto.MarshalOptions{}.MarshalAppend(frame, m)
+	if err != nil {
+		return err
+	}
+	_, err = w.writer.Write(frame)
+	return err
+}
+
+// WriteMultiBuffer implements buf.Writer.
+func (w *HunkWriter) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	defer buf.ReleaseMulti(mb)
+
+	if w.multi {
+		hunk := &MultiHunk{Data: make([][]byte, 0, len(mb))}
+		for _, b := range mb {
+			if !b.IsEmpty() {
+				hunk.Data = append(hunk.Data, b.Bytes())
+			}
+		}
+		if len(hunk.Data) == 0 {
+			return nil
+		}
+		return w.writeMessage(hunk)
+	}
+
+	for _, b := range mb {
+		if b.IsEmpty() {
+			continue
+		}
+		if err := w.writeMessage(&Hunk{Data: b.Bytes()}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close implements io.Closer. It closes the underlying writer if possible.
+func (w *HunkWriter) Close() error {
+	if closer, ok := w.writer.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package encoding_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/buf"
+	. "github.com/xtls/xray-core/transport/internet/grpc/encoding"
+)
+
+func TestHunkReadWrite(t *testing.T) {
+	for _, multi := range []bool{false, true} {
+		stream := new(bytes.Buffer)
+		writer := NewHunkWriter(stream, multi)
+
+		payload := make([]byte, buf.Size+100)
+		for i := range payload {
+			payload[i] = byte(i)
+		}
+		common.Must(writer.WriteMultiBuffer(buf.MergeBytes(nil, payload)))
+
+		reader := NewHunkReader(stream, multi)
+		var received []byte
+		for len(received) < len(payload) {
+			mb, err := reader.ReadMultiBuffer()
+			common.Must(err)
+			b := make([]byte, mb.Len())
+			buf.SplitBytes(mb, b)
+			received = append(received, b...)
+		}
+		if !bytes.Equal(received, payload) {
+			t.Error("multi: ", multi, " unexpected payload")
+		}
+	}
+}
+
+func TestHunkRejectCompressed(t *testing.T) {
+	stream := bytes.NewReader([]byte{1, 0, 0, 0, 0})
+	if _, err := NewHunkReader(stream, false).ReadMultiBuffer(); err == nil {
+		t.Error("expected error for compressed message")
+	}
+}
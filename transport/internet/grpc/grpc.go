@@ -0,0 +1,8 @@
+// Package grpc implements the gRPC-compatible "gun" transport on top of HTTP/2.
+//
+// Each connection is a bidirectional streaming call of the service configured by
+// serviceName, so it can be relayed by reverse proxies and CDNs which support gRPC.
+// Calls to the same destination share one HTTP/2 connection.
+package grpc
+
+//go:generate go run github.com/xtls/xray-core/common/errors/errorgen
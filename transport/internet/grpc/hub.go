@@ -0,0 +1,197 @@
+package grpc
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/net/cnc"
+	http_proto "github.com/xtls/xray-core/common/protocol/http"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/common/signal/done"
+	"github.com/xtls/xray-core/transport/internet"
+	"github.com/xtls/xray-core/transport/internet/grpc/encoding"
+	"github.com/xtls/xray-core/transport/internet/tls"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+type Listener struct {
+	ctx     context.Context
+	server  *http.Server
+	handler internet.ConnHandler
+	local   net.Addr
+	config  *Config
+
+	tunPath      string
+	tunMultiPath string
+}
+
+// Addr implements net.Listener.Addr().
+func (l *Listener) Addr() net.Addr {
+	return l.local
+}
+
+// Close implements net.Listener.Close().
+func (l *Listener) Close() error {
+	return l.server.Close()
+}
+
+type flushWriter struct {
+	w io.Writer
+	d *done.Instance
+}
+
+func (fw flushWriter) Write(p []byte) (n int, err error) {
+	if fw.d.Done() {
+		return 0, io.ErrClosedPipe
+	}
+
+	defer func() {
+		if recover() != nil {
+			fw.d.Close()
+			err = io.ErrClosedPipe
+		}
+	}()
+
+	n, err = fw.w.Write(p)
+	if f, ok := fw.w.(http.Flusher); ok && err == nil {
+		f.Flush()
+	}
+	return
+}
+
+func (l *Listener) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	var multi bool
+	switch request.URL.Path {
+	case l.tunPath:
+	case l.tunMultiPath:
+		multi = true
+	default:
+		writer.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if request.Method != http.MethodPost || !strings.HasPrefix(request.Header.Get("Content-Type"), "application/grpc") {
+		writer.WriteHeader(http.StatusUnsupportedMediaType)
+		return
+	}
+	if l.config.Host != "" && !internet.IsValidHTTPHost(request.Host, l.config.Host) {
+		writer.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/grpc")
+	writer.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+	writer.WriteHeader(http.StatusOK)
+	if f, ok := writer.(http.Flusher); ok {
+		f.Flush()
+	}
+
+	remoteAddr := l.Addr()
+	dest, err := net.ParseDestination(request.RemoteAddr)
+	if err != nil {
+		newError("failed to parse request remote addr: ", request.RemoteAddr).Base(err).WriteToLog(session.ExportIDToError(l.ctx))
+	} else {
+		remoteAddr = &net.TCPAddr{
+			IP:   dest.Address.IP(),
+			Port: int(dest.Port),
+		}
+	}
+
+	forwardedAddress := http_proto.ParseXForwardedFor(request.Header)
+	if len(forwardedAddress) > 0 && forwardedAddress[0].Family().IsIP() {
+		remoteAddr = &net.TCPAddr{
+			IP:   forwardedAddress[0].IP(),
+			Port: 0,
+		}
+	}
+
+	done := done.New()
+	conn := cnc.NewConnection(
+		cnc.ConnectionOutputMulti(encoding.NewHunkReader(request.Body, multi)),
+		cnc.ConnectionInputMulti(encoding.NewHunkWriter(flushWriter{w: writer, d: done}, multi)),
+		cnc.ConnectionOnClose(common.ChainedClosable{done, request.Body}),
+		cnc.ConnectionLocalAddr(l.Addr()),
+		cnc.ConnectionRemoteAddr(remoteAddr),
+	)
+	l.handler(conn)
+	<-done.Wait()
+
+	writer.Header().Set("Grpc-Status", "0")
+	writer.Header().Set("Grpc-Message", "")
+}
+
+func Listen(ctx context.Context, address net.Address, port net.Port, streamSettings *internet.MemoryStreamConfig, handler internet.ConnHandler) (internet.Listener, error) {
+	grpcSettings := streamSettings.ProtocolSettings.(*Config)
+	listener := &Listener{
+		ctx:     ctx,
+		handler: handler,
+		config:  grpcSettings,
+	}
+	listener.tunPath, listener.tunMultiPath = grpcSettings.getPaths()
+
+	var streamListener net.Listener
+	var err error
+	if port == net.Port(0) { // unix
+		listener.local = &net.UnixAddr{
+			Name: address.Domain(),
+			Net:  "unix",
+		}
+		streamListener, err = internet.ListenSystem(ctx, listener.local, streamSettings.SocketSettings)
+		if err != nil {
+			return nil, newError("failed to listen on ", address).Base(err)
+		}
+	} else { // tcp
+		listener.local = &net.TCPAddr{
+			IP:   address.IP(),
+			Port: int(port),
+		}
+		streamListener, err = internet.ListenSystem(ctx, listener.local, streamSettings.SocketSettings)
+		if err != nil {
+			return nil, newError("failed to listen on ", address, ":", port).Base(err)
+		}
+	}
+
+	if streamSettings.SocketSettings != nil && streamSettings.SocketSettings.AcceptProxyProtocol {
+		newError("accepting PROXY protocol").AtWarning().WriteToLog(session.ExportIDToError(ctx))
+	}
+
+	h2s := &http2.Server{}
+	if grpcSettings.IdleTimeout > 0 {
+		h2s.IdleTimeout = time.Second * time.Duration(grpcSettings.IdleTimeout)
+	}
+
+	config := tls.ConfigFromStreamSettings(streamSettings)
+	server := &http.Server{
+		ReadHeaderTimeout: time.Second * 4,
+	}
+	if config == nil {
+		server.Handler = h2c.NewHandler(listener, h2s)
+	} else {
+		server.Handler = listener
+		server.TLSConfig = config.GetTLSConfig(tls.WithNextProto(http2.NextProtoTLS))
+		common.Must(http2.ConfigureServer(server, h2s))
+	}
+	listener.server = server
+
+	go func() {
+		if config == nil {
+			err = server.Serve(streamListener)
+		} else {
+			err = server.ServeTLS(streamListener, "", "")
+		}
+		if err != nil {
+			newError("stopping serving gRPC").Base(err).WriteToLog(session.ExportIDToError(ctx))
+		}
+	}()
+
+	return listener, nil
+}
+
+func init() {
+	common.Must(internet.RegisterTransportListener(protocolName, Listen))
+}
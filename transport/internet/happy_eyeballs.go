@@ -0,0 +1,156 @@
+package internet
+
+import (
+	"context"
+	"time"
+
+	"github.com/xtls/xray-core/common/dice"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/session"
+)
+
+const (
+	defaultHappyEyeballsTryDelay      = 250 * time.Millisecond
+	defaultHappyEyeballsMaxConcurrent = 4
+)
+
+// DialHappyEyeballs races connections to the given addresses as described by
+// RFC 8305. Attempts start one after another, every try delay or as soon as
+// the previous attempt fails, alternating between address families. The first
+// established connection is returned and the other attempts are cancelled.
+//
+// Each attempt dials with its own copy of the session outbound, as dialers
+// record the gateway and the connection there; the copy of the winning attempt
+// is written back when it returns.
+func DialHappyEyeballs(ctx context.Context, ips []net.IP, port net.Port, config *HappyEyeballsConfig, dial func(context.Context, net.Destination) (net.Conn, error)) (net.Conn, error) {
+	if len(ips) == 0 {
+		return nil, newError("no address to dial")
+	}
+	tryDelay := time.Duration(config.GetTryDelayMs()) * time.Millisecond
+	if tryDelay <= 0 {
+		tryDelay = defaultHappyEyeballsTryDelay
+	}
+	maxConcurrent := int(config.GetMaxConcurrentTry())
+	if maxConcurrent <= 0 {
+		maxConcurrent = defaultHappyEyeballsMaxConcurrent
+	}
+	ips = sortHappyEyeballs(ips, config.GetPreferIpv4(), int(config.GetInterleave()))
+
+	outbound := session.OutboundFromContext(ctx)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type dialResult struct {
+		conn     net.Conn
+		err      error
+		outbound *session.Outbound
+	}
+	results := make(chan dialResult)
+	done := make(chan struct{})
+	defer close(done)
+
+	next, inFlight := 0, 0
+	start := func() {
+		dest := net.TCPDestination(net.IPAddress(ips[next]), port)
+		next++
+		inFlight++
+		attemptCtx := ctx
+		var attempt *session.Outbound
+		if outbound != nil {
+			copied := *outbound
+			attempt = &copied
+			attemptCtx = session.ContextWithOutbound(ctx, attempt)
+		}
+		go func() {
+			conn, err := dial(attemptCtx, dest)
+			select {
+			case results <- dialResult{conn, err, attempt}:
+			case <-done:
+				if conn != nil {
+					conn.Close()
+				}
+			}
+		}()
+	}
+
+	start()
+	timer := time.NewTimer(tryDelay)
+	defer timer.Stop()
+
+	var firstErr error
+	for {
+		select {
+		case result := <-results:
+			inFlight--
+			if result.err == nil {
+				if outbound != nil {
+					*outbound = *result.outbound
+				}
+				return result.conn, nil
+			}
+			newError("happy eyeballs attempt failed").Base(result.err).AtDebug().WriteToLog(session.ExportIDToError(ctx))
+			if firstErr == nil {
+				firstErr = result.err
+			}
+			if next < len(ips) {
+				start()
+				if !timer.Stop() {
+					select {
+					case <-timer.C:
+					default:
+					}
+				}
+				timer.Reset(tryDelay)
+			} else if inFlight == 0 {
+				return nil, firstErr
+			}
+		case <-timer.C:
+			if next < len(ips) && inFlight < maxConcurrent {
+				start()
+			}
+			timer.Reset(tryDelay)
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// sortHappyEyeballs orders ips for connection attempts: interleave addresses
+// of the preferred family first, then the families alternate. The addresses
+// of each family are shuffled to spread the load like a random pick would.
+func sortHappyEyeballs(ips []net.IP, preferIPv4 bool, interleave int) []net.IP {
+	if interleave <= 0 {
+		interleave = 1
+	}
+	var preferred, other []net.IP
+	for _, ip := range ips {
+		if (ip.To4() != nil) == preferIPv4 {
+			preferred = append(preferred, ip)
+		} else {
+			other = append(other, ip)
+		}
+	}
+	shuffleIPs(preferred)
+	shuffleIPs(other)
+
+	sorted := make([]net.IP, 0, len(ips))
+	for n := interleave; len(preferred) > 0 || len(other) > 0; n = 1 {
+		if n > len(preferred) {
+			n = len(preferred)
+		}
+		sorted = append(sorted, preferred[:n]...)
+		preferred = preferred[n:]
+		if len(other) > 0 {
+			sorted = append(sorted, other[0])
+			other = other[1:]
+		}
+	}
+	return sorted
+}
+
+func shuffleIPs(ips []net.IP) {
+	for i := len(ips) - 1; i > 0; i-- {
+		j := dice.Roll(i + 1)
+		ips[i], ips[j] = ips[j], ips[i]
+	}
+}
@@ -0,0 +1,187 @@
+package internet_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/transport/internet"
+)
+
+func dialTCP(ctx context.Context, dest net.Destination) (net.Conn, error) {
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, "tcp", dest.NetAddr())
+}
+
+func TestHappyEyeballsRefused(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	common.Must(err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	port := net.Port(listener.Addr().(*net.TCPAddr).Port)
+
+	// Nothing listens on 127.0.0.2, so the attempt is refused and the next
+	// address is tried without waiting for the try delay.
+	start := time.Now()
+	conn, err := internet.DialHappyEyeballs(context.Background(), []net.IP{net.ParseIP("127.0.0.2"), net.ParseIP("127.0.0.1")}, port, &internet.HappyEyeballsConfig{TryDelayMs: 5000}, dialTCP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if ip := conn.RemoteAddr().(*net.TCPAddr).IP; !ip.Equal(net.ParseIP("127.0.0.1")) {
+		t.Error("connected to ", ip)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Error("waited for the try delay after a refused attempt: ", elapsed)
+	}
+
+	if _, err := internet.DialHappyEyeballs(context.Background(), []net.IP{net.ParseIP("127.0.0.2")}, port, nil, dialTCP); err == nil {
+		t.Error("expected an error when all attempts are refused")
+	}
+}
+
+func TestHappyEyeballsDelay(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	common.Must(err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	port := net.Port(listener.Addr().(*net.TCPAddr).Port)
+
+	// The IPv6 address stalls like a host with broken IPv6 connectivity.
+	cancelled := make(chan struct{})
+	dial := func(ctx context.Context, dest net.Destination) (net.Conn, error) {
+		if dest.Address.Family().IsIPv6() {
+			<-ctx.Done()
+			close(cancelled)
+			return nil, ctx.Err()
+		}
+		return dialTCP(ctx, dest)
+	}
+
+	start := time.Now()
+	conn, err := internet.DialHappyEyeballs(context.Background(), []net.IP{net.ParseIP("2001:db8::1"), net.ParseIP("127.0.0.1")}, port, &internet.HappyEyeballsConfig{TryDelayMs: 100}, dial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond || elapsed > 2*time.Second {
+		t.Error("unexpected time to connect: ", elapsed)
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Error("the stalled attempt is not cancelled")
+	}
+}
+
+func TestHappyEyeballsOutbound(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	common.Must(err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	port := net.Port(listener.Addr().(*net.TCPAddr).Port)
+
+	// The IPv6 attempt is still running when the IPv4 one connects. Both
+	// record their gateway and connection in the session outbound, like the
+	// outbound handler does.
+	dial := func(ctx context.Context, dest net.Destination) (net.Conn, error) {
+		outbound := session.OutboundFromContext(ctx)
+		outbound.Gateway = dest.Address
+		if dest.Address.Family().IsIPv6() {
+			<-ctx.Done()
+			outbound.Conn = nil
+			return nil, ctx.Err()
+		}
+		conn, err := dialTCP(ctx, dest)
+		outbound.Conn = conn
+		return conn, err
+	}
+
+	outbound := &session.Outbound{Name: "freedom"}
+	ctx := session.ContextWithOutbound(context.Background(), outbound)
+	conn, err := internet.DialHappyEyeballs(ctx, []net.IP{net.ParseIP("2001:db8::1"), net.ParseIP("127.0.0.1")}, port, &internet.HappyEyeballsConfig{TryDelayMs: 10}, dial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if outbound.Gateway != net.ParseAddress("127.0.0.1") || outbound.Conn != conn || outbound.Name != "freedom" {
+		t.Error("unexpected outbound: ", outbound)
+	}
+}
+
+func TestHappyEyeballsOrder(t *testing.T) {
+	ips := []net.IP{
+		net.ParseIP("2001:db8::1"),
+		net.ParseIP("2001:db8::2"),
+		net.ParseIP("2001:db8::3"),
+		net.ParseIP("2001:db8::4"),
+		net.ParseIP("192.0.2.1"),
+		net.ParseIP("192.0.2.2"),
+	}
+	testCases := []struct {
+		config *internet.HappyEyeballsConfig
+		order  string
+	}{
+		{
+			config: nil,
+			order:  "646466",
+		},
+		{
+			config: &internet.HappyEyeballsConfig{Interleave: 2},
+			order:  "664646",
+		},
+		{
+			config: &internet.HappyEyeballsConfig{PreferIpv4: true},
+			order:  "464666",
+		},
+	}
+	for _, tc := range testCases {
+		var access sync.Mutex
+		var order string
+		refuse := func(ctx context.Context, dest net.Destination) (net.Conn, error) {
+			access.Lock()
+			defer access.Unlock()
+			if dest.Address.Family().IsIPv4() {
+				order += "4"
+			} else {
+				order += "6"
+			}
+			return nil, errors.New("connection refused")
+		}
+		if _, err := internet.DialHappyEyeballs(context.Background(), ips, 443, tc.config, refuse); err == nil {
+			t.Error("expected an error when all attempts are refused")
+		}
+		if order != tc.order {
+			t.Error("order: ", order, ", want ", tc.order)
+		}
+	}
+}
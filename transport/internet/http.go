@@ -0,0 +1,17 @@
+package internet
+
+import (
+	"net"
+	"strings"
+)
+
+// IsValidHTTPHost checks whether the Host of an HTTP request matches the configured one, ignoring the port.
+func IsValidHTTPHost(request string, config string) bool {
+	r := strings.ToLower(request)
+	c := strings.ToLower(config)
+	if strings.Contains(r, ":") {
+		h, _, _ := net.SplitHostPort(r)
+		return h == c
+	}
+	return r == c
+}
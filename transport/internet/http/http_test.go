@@ -0,0 +1,106 @@
+package http_test
+
+import (
+	"context"
+	"crypto/rand"
+	gonet "net"
+	"testing"
+	"time"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/protocol/tls/cert"
+	"github.com/xtls/xray-core/transport/internet"
+	. "github.com/xtls/xray-core/transport/internet/http"
+	"github.com/xtls/xray-core/transport/internet/stat"
+	"github.com/xtls/xray-core/transport/internet/tls"
+)
+
+func pickPort() net.Port {
+	listener, err := gonet.Listen("tcp4", "127.0.0.1:0")
+	common.Must(err)
+	defer listener.Close()
+
+	addr := listener.Addr().(*gonet.TCPAddr)
+	return net.Port(addr.Port)
+}
+
+func echo(conn stat.Connection) {
+	go func() {
+		defer conn.Close()
+
+		b := buf.New()
+		defer b.Release()
+
+		for {
+			b.Clear()
+			if _, err := b.ReadFrom(conn); err != nil {
+				return
+			}
+			if _, err := conn.Write(b.Bytes()); err != nil {
+				return
+			}
+		}
+	}()
+}
+
+func testRoundTrip(t *testing.T, streamSettings *internet.MemoryStreamConfig, dialSettings *internet.MemoryStreamConfig) {
+	port := pickPort()
+	listener, err := Listen(context.Background(), net.LocalHostIP, port, streamSettings, echo)
+	common.Must(err)
+	defer listener.Close()
+
+	time.Sleep(time.Second)
+
+	dctx := context.Background()
+	conn, err := Dial(dctx, net.TCPDestination(net.LocalHostIP, port), dialSettings)
+	common.Must(err)
+	defer conn.Close()
+
+	const N = 1024
+	b1 := make([]byte, N)
+	common.Must2(rand.Read(b1))
+	b2 := buf.New()
+
+	nBytes, err := conn.Write(b1)
+	common.Must(err)
+	if nBytes != N {
+		t.Error("write: ", nBytes)
+	}
+
+	b2.Clear()
+	common.Must2(b2.ReadFullFrom(conn, N))
+	if r := b2.Bytes(); string(r) != string(b1) {
+		t.Error("unexpected response")
+	}
+}
+
+func TestHTTPConnection(t *testing.T) {
+	testRoundTrip(t, &internet.MemoryStreamConfig{
+		ProtocolName:     "http",
+		ProtocolSettings: &Config{},
+		SecurityType:     "tls",
+		SecuritySettings: &tls.Config{
+			Certificate: []*tls.Certificate{tls.ParseCertificate(cert.MustGenerate(nil, cert.CommonName("www.example.com")))},
+		},
+	}, &internet.MemoryStreamConfig{
+		ProtocolName:     "http",
+		ProtocolSettings: &Config{},
+		SecurityType:     "tls",
+		SecuritySettings: &tls.Config{
+			ServerName:    "www.example.com",
+			AllowInsecure: true,
+		},
+	})
+}
+
+func TestH2CConnection(t *testing.T) {
+	testRoundTrip(t, &internet.MemoryStreamConfig{
+		ProtocolName:     "http",
+		ProtocolSettings: &Config{Path: "/h2c"},
+	}, &internet.MemoryStreamConfig{
+		ProtocolName:     "http",
+		ProtocolSettings: &Config{Path: "/h2c"},
+	})
+}
@@ -0,0 +1,14 @@
+package kcp
+
+import (
+	"crypto/cipher"
+	"crypto/sha256"
+
+	"github.com/xtls/xray-core/common/crypto"
+)
+
+// NewAEADAESGCMBasedOnSeed creates an AES-128-GCM AEAD whose key is derived from the given seed.
+func NewAEADAESGCMBasedOnSeed(seed string) cipher.AEAD {
+	hashedSeed := sha256.Sum256([]byte(seed))
+	return crypto.NewAesGcm(hashedSeed[:16])
+}
@@ -0,0 +1,9 @@
+// Package kcp implements mKCP, a reliable transport over UDP based on the KCP ARQ protocol.
+//
+// Acknowledgement:
+//
+//	skywind3000@github for inventing the KCP protocol
+//	xtaci@github for translating to Golang
+package kcp
+
+//go:generate go run github.com/xtls/xray-core/common/errors/errorgen
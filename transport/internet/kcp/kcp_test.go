@@ -0,0 +1,100 @@
+package kcp_test
+
+import (
+	"context"
+	"crypto/rand"
+	"io"
+	gonet "net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/serial"
+	"github.com/xtls/xray-core/transport/internet"
+	"github.com/xtls/xray-core/transport/internet/headers/wechat"
+	. "github.com/xtls/xray-core/transport/internet/kcp"
+	"github.com/xtls/xray-core/transport/internet/stat"
+)
+
+func pickUDPPort() net.Port {
+	conn, err := gonet.ListenPacket("udp4", "127.0.0.1:0")
+	common.Must(err)
+	defer conn.Close()
+
+	return net.Port(conn.LocalAddr().(*gonet.UDPAddr).Port)
+}
+
+func TestDialAndListen(t *testing.T) {
+	config := &Config{
+		HeaderConfig: serial.ToTypedMessage(&wechat.VideoConfig{}),
+		Seed:         &EncryptionSeed{Seed: "test seed"},
+	}
+	streamSettings := &internet.MemoryStreamConfig{
+		ProtocolName:     "mkcp",
+		ProtocolSettings: config,
+	}
+
+	port := pickUDPPort()
+	listener, err := NewListener(context.Background(), config, net.LocalHostIP, port, streamSettings, func(conn stat.Connection) {
+		go func(c stat.Connection) {
+			payload := make([]byte, 4096)
+			for {
+				nBytes, err := c.Read(payload)
+				if err != nil {
+					break
+				}
+				for idx, b := range payload[:nBytes] {
+					payload[idx] = b ^ 'c'
+				}
+				c.Write(payload[:nBytes])
+			}
+			c.Close()
+		}(conn)
+	})
+	common.Must(err)
+	defer listener.Close()
+
+	const N = 10
+	var wg sync.WaitGroup
+	wg.Add(N)
+	for i := 0; i < N; i++ {
+		go func() {
+			defer wg.Done()
+
+			clientConn, err := DialKCP(context.Background(), net.UDPDestination(net.LocalHostIP, port), streamSettings)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer clientConn.Close()
+
+			clientSend := make([]byte, 1024*1024)
+			common.Must2(rand.Read(clientSend))
+			go clientConn.Write(clientSend)
+
+			clientReceived := make([]byte, 1024*1024)
+			if _, err := io.ReadFull(clientConn, clientReceived); err != nil {
+				t.Error(errors.New("failed to read response").Base(err))
+				return
+			}
+
+			for idx, b := range clientSend {
+				if clientReceived[idx] != b^'c' {
+					t.Error("unexpected byte at ", idx)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := 0; i < 60 && listener.ActiveConnections() > 0; i++ {
+		time.Sleep(500 * time.Millisecond)
+	}
+	if v := listener.ActiveConnections(); v != 0 {
+		t.Error("active connections: ", v)
+	}
+}
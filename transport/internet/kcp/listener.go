@@ -0,0 +1,204 @@
+package kcp
+
+import (
+	"context"
+	"crypto/cipher"
+	gotls "crypto/tls"
+	"sync"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/transport/internet"
+	"github.com/xtls/xray-core/transport/internet/stat"
+	"github.com/xtls/xray-core/transport/internet/tls"
+)
+
+type ConnectionID struct {
+	Remote net.Address
+	Port   net.Port
+	Conv   uint16
+}
+
+// Listener defines a server listening for connections
+type Listener struct {
+	sync.Mutex
+	sessions  map[ConnectionID]*Connection
+	conn      net.PacketConn
+	tlsConfig *gotls.Config
+	config    *Config
+	reader    PacketReader
+	security  cipher.AEAD
+	addConn   internet.ConnHandler
+}
+
+func NewListener(ctx context.Context, config *Config, address net.Address, port net.Port, streamSettings *internet.MemoryStreamConfig, addConn internet.ConnHandler) (*Listener, error) {
+	header, err := config.GetPackerHeader()
+	if err != nil {
+		return nil, newError("failed to create packet header").Base(err).AtError()
+	}
+	security, err := config.GetSecurity()
+	if err != nil {
+		return nil, newError("failed to create security").Base(err).AtError()
+	}
+	l := &Listener{
+		security: security,
+		reader: &KCPPacketReader{
+			Header:   header,
+			Security: security,
+		},
+		sessions: make(map[ConnectionID]*Connection),
+		config:   config,
+		addConn:  addConn,
+	}
+
+	if config := tls.ConfigFromStreamSettings(streamSettings); config != nil {
+		l.tlsConfig = config.GetTLSConfig()
+	}
+
+	conn, err := internet.ListenSystemPacket(ctx, &net.UDPAddr{
+		IP:   address.IP(),
+		Port: int(port),
+	}, streamSettings.SocketSettings)
+	if err != nil {
+		return nil, newError("failed to listen on ", address, ":", port).Base(err)
+	}
+	l.conn = conn
+	newError("listening on ", address, ":", port).WriteToLog()
+
+	go l.handlePackets()
+
+	return l, nil
+}
+
+func (l *Listener) handlePackets() {
+	for {
+		payload := buf.New()
+		n, addr, err := l.conn.ReadFrom(payload.Extend(buf.Size))
+		if err != nil {
+			payload.Release()
+			return
+		}
+		payload.Resize(0, int32(n))
+		l.OnReceive(payload, net.DestinationFromAddr(addr))
+	}
+}
+
+func (l *Listener) OnReceive(payload *buf.Buffer, src net.Destination) {
+	segments := l.reader.Read(payload.Bytes())
+	payload.Release()
+
+	if len(segments) == 0 {
+		newError("discarding invalid payload from ", src).WriteToLog()
+		return
+	}
+
+	conv := segments[0].Conversation()
+	cmd := segments[0].Command()
+
+	id := ConnectionID{
+		Remote: src.Address,
+		Port:   src.Port,
+		Conv:   conv,
+	}
+
+	l.Lock()
+	defer l.Unlock()
+
+	conn, found := l.sessions[id]
+
+	if !found {
+		if cmd == CommandTerminate {
+			return
+		}
+		// Packet headers may keep state like sequence numbers, so each connection gets its own.
+		header, err := l.config.GetPackerHeader()
+		if err != nil {
+			newError("failed to create packet header").Base(err).WriteToLog()
+			return
+		}
+		writer := &Writer{
+			id:   id,
+			conn: l.conn,
+			dest: &net.UDPAddr{
+				IP:   src.Address.IP(),
+				Port: int(src.Port),
+			},
+			listener: l,
+		}
+		conn = NewConnection(ConnMetadata{
+			LocalAddr:    l.conn.LocalAddr(),
+			RemoteAddr:   writer.dest,
+			Conversation: conv,
+		}, &KCPPacketWriter{
+			Header:   header,
+			Security: l.security,
+			Writer:   writer,
+		}, writer, l.config)
+		var netConn stat.Connection = conn
+		if l.tlsConfig != nil {
+			netConn = tls.Server(conn, l.tlsConfig)
+		}
+
+		l.addConn(netConn)
+		l.sessions[id] = conn
+	}
+	conn.Input(segments)
+}
+
+func (l *Listener) Remove(id ConnectionID) {
+	l.Lock()
+	delete(l.sessions, id)
+	l.Unlock()
+}
+
+// Close stops listening on the UDP address, and terminates all connections accepted by this listener.
+func (l *Listener) Close() error {
+	l.conn.Close()
+
+	l.Lock()
+	defer l.Unlock()
+
+	for _, conn := range l.sessions {
+		go conn.Terminate()
+	}
+
+	return nil
+}
+
+func (l *Listener) ActiveConnections() int {
+	l.Lock()
+	defer l.Unlock()
+
+	return len(l.sessions)
+}
+
+// Addr returns the listener's network address, The Addr returned is shared by all invocations of Addr, so do not modify it.
+func (l *Listener) Addr() net.Addr {
+	return l.conn.LocalAddr()
+}
+
+// Writer sends the packets of one connection to its remote address, through the shared listening socket.
+type Writer struct {
+	id       ConnectionID
+	dest     net.Addr
+	conn     net.PacketConn
+	listener *Listener
+}
+
+func (w *Writer) Write(payload []byte) (int, error) {
+	return w.conn.WriteTo(payload, w.dest)
+}
+
+func (w *Writer) Close() error {
+	w.listener.Remove(w.id)
+	return nil
+}
+
+func ListenKCP(ctx context.Context, address net.Address, port net.Port, streamSettings *internet.MemoryStreamConfig, addConn internet.ConnHandler) (internet.Listener, error) {
+	return NewListener(ctx, streamSettings.ProtocolSettings.(*Config), address, port, streamSettings, addConn)
+}
+
+func init() {
+	common.Must(internet.RegisterTransportListener(protocolName, ListenKCP))
+}
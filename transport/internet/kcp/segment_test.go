@@ -0,0 +1,104 @@
+package kcp_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/xtls/xray-core/common"
+	. "github.com/xtls/xray-core/transport/internet/kcp"
+)
+
+func TestBadSegment(t *testing.T) {
+	seg, buf := ReadSegment(nil)
+	if seg != nil {
+		t.Error("non-nil seg")
+	}
+	if len(buf) != 0 {
+		t.Error("buf len: ", len(buf))
+	}
+}
+
+func TestDataSegment(t *testing.T) {
+	seg := &DataSegment{
+		Conv:        1,
+		Timestamp:   3,
+		Number:      4,
+		SendingNext: 5,
+	}
+	seg.Data().Write([]byte{'a', 'b', 'c', 'd'})
+
+	nBytes := seg.ByteSize()
+	b := make([]byte, nBytes)
+	seg.Serialize(b)
+
+	iseg, _ := ReadSegment(b)
+	seg2 := iseg.(*DataSegment)
+	if seg2.Conv != seg.Conv || seg2.Timestamp != seg.Timestamp || seg2.Number != seg.Number || seg2.SendingNext != seg.SendingNext {
+		t.Error("unexpected segment: ", seg2)
+	}
+	if !bytes.Equal(seg2.Data().Bytes(), seg.Data().Bytes()) {
+		t.Error("unexpected data: ", seg2.Data().Bytes())
+	}
+}
+
+func TestACKSegment(t *testing.T) {
+	seg := &AckSegment{
+		Conv:            1,
+		ReceivingWindow: 2,
+		ReceivingNext:   3,
+		Timestamp:       10,
+		NumberList:      []uint32{1, 3, 5, 7, 9},
+	}
+
+	nBytes := seg.ByteSize()
+	b := make([]byte, nBytes)
+	seg.Serialize(b)
+
+	iseg, _ := ReadSegment(b)
+	seg2 := iseg.(*AckSegment)
+	if !reflect.DeepEqual(seg2, seg) {
+		t.Error("unexpected segment: ", seg2)
+	}
+}
+
+func TestCmdSegment(t *testing.T) {
+	seg := &CmdOnlySegment{
+		Conv:          1,
+		Cmd:           CommandPing,
+		Option:        SegmentOptionClose,
+		SendingNext:   11,
+		ReceivingNext: 13,
+		PeerRTO:       15,
+	}
+
+	nBytes := seg.ByteSize()
+	b := make([]byte, nBytes)
+	seg.Serialize(b)
+
+	iseg, _ := ReadSegment(b)
+	seg2 := iseg.(*CmdOnlySegment)
+	if !reflect.DeepEqual(seg2, seg) {
+		t.Error("unexpected segment: ", seg2)
+	}
+}
+
+func TestSimpleAuthenticator(t *testing.T) {
+	cache := make([]byte, 512)
+
+	payload := []byte{'a', 'b', 'c', 'd', 'e', 'f', 'g'}
+
+	auth := NewSimpleAuthenticator()
+	b := auth.Seal(cache[:0], nil, payload, nil)
+	c, err := auth.Open(cache[:0], nil, b, nil)
+	common.Must(err)
+	if !bytes.Equal(c, payload) {
+		t.Error("unexpected payload: ", c)
+	}
+
+	b = auth.Seal(cache[:0], nil, payload, nil)
+	b[len(b)-1]++
+	if _, err := auth.Open(nil, nil, b, nil); err == nil {
+		t.Error("expected auth failure on tampered packet")
+	}
+}
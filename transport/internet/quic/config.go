@@ -0,0 +1,43 @@
+package quic
+
+import (
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/transport/internet"
+)
+
+// getHeader returns the packet header configured by header, or nil if not set.
+func (c *Config) getHeader() (internet.PacketHeader, error) {
+	if c.Header == nil {
+		return nil, nil
+	}
+
+	msg, err := c.Header.GetInstance()
+	if err != nil {
+		return nil, err
+	}
+
+	return internet.CreatePacketHeader(msg)
+}
+
+func (c *Config) getQUICConfig() *quic.Config {
+	config := &quic.Config{
+		KeepAlivePeriod:       time.Second * time.Duration(c.KeepAlivePeriod),
+		HandshakeIdleTimeout:  time.Second * 8,
+		MaxIdleTimeout:        time.Second * 300,
+		MaxIncomingStreams:    32,
+		MaxIncomingUniStreams: -1,
+	}
+	if c.MaxIdleTimeout > 0 {
+		config.MaxIdleTimeout = time.Second * time.Duration(c.MaxIdleTimeout)
+	}
+	return config
+}
+
+func init() {
+	common.Must(internet.RegisterProtocolConfigCreator(protocolName, func() interface{} {
+		return new(Config)
+	}))
+}
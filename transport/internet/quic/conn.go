@@ -0,0 +1,139 @@
+package quic
+
+import (
+	"sync"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/bytespool"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/transport/internet"
+)
+
+// sysConn is the UDP socket of QUIC connections. It applies the packet header to all datagrams.
+type sysConn struct {
+	net.PacketConn
+
+	access sync.Mutex
+	header internet.PacketHeader
+}
+
+func wrapSysConn(rawConn net.PacketConn, config *Config) (*sysConn, error) {
+	header, err := config.getHeader()
+	if err != nil {
+		return nil, err
+	}
+	return &sysConn{
+		PacketConn: rawConn,
+		header:     header,
+	}, nil
+}
+
+func (c *sysConn) ReadFrom(p []byte) (int, net.Addr, error) {
+	if c.header == nil {
+		return c.PacketConn.ReadFrom(p)
+	}
+
+	overhead := int(c.header.Size())
+	buffer := bytespool.Alloc(int32(len(p) + overhead))
+	defer bytespool.Free(buffer)
+
+	for {
+		nBytes, addr, err := c.PacketConn.ReadFrom(buffer[:len(p)+overhead])
+		if err != nil {
+			return 0, nil, err
+		}
+		if nBytes < overhead {
+			newError("discarding short packet from ", addr).AtDebug().WriteToLog()
+			continue
+		}
+		return copy(p, buffer[overhead:nBytes]), addr, nil
+	}
+}
+
+func (c *sysConn) WriteTo(p []byte, addr net.Addr) (int, error) {
+	if c.header == nil {
+		return c.PacketConn.WriteTo(p, addr)
+	}
+
+	overhead := int(c.header.Size())
+	buffer := bytespool.Alloc(int32(len(p) + overhead))
+	defer bytespool.Free(buffer)
+
+	// Packet headers may keep state like sequence numbers, and all QUIC connections of a listener share this socket.
+	c.access.Lock()
+	c.header.Serialize(buffer)
+	c.access.Unlock()
+
+	copy(buffer[overhead:], p)
+	nBytes, err := c.PacketConn.WriteTo(buffer[:len(p)+overhead], addr)
+	if err != nil {
+		return 0, err
+	}
+	return nBytes - overhead, nil
+}
+
+// SetReadBuffer allows quic-go to enlarge the receive buffer of the underlying socket.
+func (c *sysConn) SetReadBuffer(bytes int) error {
+	if conn, ok := c.PacketConn.(interface{ SetReadBuffer(int) error }); ok {
+		return conn.SetReadBuffer(bytes)
+	}
+	return nil
+}
+
+// SetWriteBuffer allows quic-go to enlarge the send buffer of the underlying socket.
+func (c *sysConn) SetWriteBuffer(bytes int) error {
+	if conn, ok := c.PacketConn.(interface{ SetWriteBuffer(int) error }); ok {
+		return conn.SetWriteBuffer(bytes)
+	}
+	return nil
+}
+
+// interConn is a connection over a single QUIC stream.
+type interConn struct {
+	stream quic.Stream
+	local  net.Addr
+	remote net.Addr
+}
+
+func (c *interConn) Read(b []byte) (int, error) {
+	return c.stream.Read(b)
+}
+
+func (c *interConn) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	mb = buf.Compact(mb)
+	mb, err := buf.WriteMultiBuffer(c, mb)
+	buf.ReleaseMulti(mb)
+	return err
+}
+
+func (c *interConn) Write(b []byte) (int, error) {
+	return c.stream.Write(b)
+}
+
+// Close closes both directions of the stream.
+func (c *interConn) Close() error {
+	c.stream.CancelRead(0)
+	return c.stream.Close()
+}
+
+func (c *interConn) LocalAddr() net.Addr {
+	return c.local
+}
+
+func (c *interConn) RemoteAddr() net.Addr {
+	return c.remote
+}
+
+func (c *interConn) SetDeadline(t time.Time) error {
+	return c.stream.SetDeadline(t)
+}
+
+func (c *interConn) SetReadDeadline(t time.Time) error {
+	return c.stream.SetReadDeadline(t)
+}
+
+func (c *interConn) SetWriteDeadline(t time.Time) error {
+	return c.stream.SetWriteDeadline(t)
+}
@@ -0,0 +1,214 @@
+package quic
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/common/task"
+	"github.com/xtls/xray-core/transport/internet"
+	"github.com/xtls/xray-core/transport/internet/stat"
+	"github.com/xtls/xray-core/transport/internet/tls"
+)
+
+type connectionContext struct {
+	rawConn   *sysConn
+	transport *quic.Transport
+	conn      quic.Connection
+}
+
+var errConnectionClosed = newError("connection closed")
+
+func (c *connectionContext) openStream(destAddr net.Addr) (*interConn, error) {
+	if !isActive(c.conn) {
+		return nil, errConnectionClosed
+	}
+
+	stream, err := c.conn.OpenStream()
+	if err != nil {
+		return nil, err
+	}
+
+	conn := &interConn{
+		stream: stream,
+		local:  c.conn.LocalAddr(),
+		remote: destAddr,
+	}
+
+	return conn, nil
+}
+
+func (c *connectionContext) close() {
+	if err := c.conn.CloseWithError(0, ""); err != nil {
+		newError("failed to close connection").Base(err).WriteToLog()
+	}
+	c.transport.Close()
+	if err := c.rawConn.Close(); err != nil {
+		newError("failed to close raw connection").Base(err).WriteToLog()
+	}
+}
+
+type dialerConf struct {
+	net.Destination
+	*internet.MemoryStreamConfig
+}
+
+type clientConnections struct {
+	access  sync.Mutex
+	conns   map[dialerConf][]*connectionContext
+	cleanup *task.Periodic
+}
+
+func isActive(s quic.Connection) bool {
+	select {
+	case <-s.Context().Done():
+		return false
+	default:
+		return true
+	}
+}
+
+func removeInactiveConnections(conns []*connectionContext) []*connectionContext {
+	activeConnections := make([]*connectionContext, 0, len(conns))
+	for _, s := range conns {
+		if isActive(s.conn) {
+			activeConnections = append(activeConnections, s)
+			continue
+		}
+		s.close()
+	}
+
+	if len(activeConnections) < len(conns) {
+		newError("active QUIC connections reduced from ", len(conns), " to ", len(activeConnections)).AtDebug().WriteToLog()
+		return activeConnections
+	}
+
+	return conns
+}
+
+func (s *clientConnections) cleanConnections() error {
+	s.access.Lock()
+	defer s.access.Unlock()
+
+	if len(s.conns) == 0 {
+		return nil
+	}
+
+	newConnMap := make(map[dialerConf][]*connectionContext)
+
+	for key, conns := range s.conns {
+		conns = removeInactiveConnections(conns)
+		if len(conns) > 0 {
+			newConnMap[key] = conns
+		}
+	}
+
+	s.conns = newConnMap
+	return nil
+}
+
+func (s *clientConnections) openConnection(ctx context.Context, dest net.Destination, destAddr *net.UDPAddr, streamSettings *internet.MemoryStreamConfig, tlsConfig *tls.Config) (stat.Connection, error) {
+	s.access.Lock()
+	defer s.access.Unlock()
+
+	key := dialerConf{dest, streamSettings}
+	conns := s.conns[key]
+
+	if len(conns) > 0 {
+		c := conns[len(conns)-1]
+		if isActive(c.conn) {
+			conn, err := c.openStream(destAddr)
+			if err == nil {
+				return conn, nil
+			}
+			newError("failed to open stream on existing QUIC connection").Base(err).AtDebug().WriteToLog(session.ExportIDToError(ctx))
+		}
+	}
+
+	conns = removeInactiveConnections(conns)
+	newError("dialing QUIC to ", dest).WriteToLog(session.ExportIDToError(ctx))
+
+	rawConn, err := internet.DialSystem(ctx, net.UDPDestination(net.IPAddress(destAddr.IP), dest.Port), streamSettings.SocketSettings)
+	if err != nil {
+		return nil, newError("failed to dial to dest: ", err).AtWarning().Base(err)
+	}
+
+	packetConn, ok := rawConn.(*internet.PacketConnWrapper)
+	if !ok {
+		rawConn.Close()
+		return nil, newError("QUIC with bound address or dialer proxy is unsupported").AtWarning()
+	}
+
+	config := streamSettings.ProtocolSettings.(*Config)
+	sysConn, err := wrapSysConn(packetConn.Conn, config)
+	if err != nil {
+		rawConn.Close()
+		return nil, err
+	}
+
+	tr := &quic.Transport{
+		ConnectionIDLength: 12,
+		Conn:               sysConn,
+	}
+	conn, err := tr.Dial(ctx, destAddr, tlsConfig.GetTLSConfig(tls.WithDestination(dest)), config.getQUICConfig())
+	if err != nil {
+		tr.Close()
+		sysConn.Close()
+		return nil, err
+	}
+
+	connCtx := &connectionContext{
+		rawConn:   sysConn,
+		transport: tr,
+		conn:      conn,
+	}
+	s.conns[key] = append(conns, connCtx)
+	return connCtx.openStream(destAddr)
+}
+
+var client clientConnections
+
+func init() {
+	client.conns = make(map[dialerConf][]*connectionContext)
+	client.cleanup = &task.Periodic{
+		Interval: time.Minute,
+		Execute:  client.cleanConnections,
+	}
+	common.Must(client.cleanup.Start())
+}
+
+// Dial opens a new stream to the given destination, on an existing QUIC connection when possible.
+func Dial(ctx context.Context, dest net.Destination, streamSettings *internet.MemoryStreamConfig) (stat.Connection, error) {
+	tlsConfig := tls.ConfigFromStreamSettings(streamSettings)
+	if tlsConfig == nil {
+		// QUIC always runs over TLS. Without TLS settings, the server uses a self-signed certificate.
+		tlsConfig = &tls.Config{
+			ServerName:    internalDomain,
+			AllowInsecure: true,
+		}
+	}
+
+	var destAddr *net.UDPAddr
+	if dest.Address.Family().IsIP() {
+		destAddr = &net.UDPAddr{
+			IP:   dest.Address.IP(),
+			Port: int(dest.Port),
+		}
+	} else {
+		addr, err := net.ResolveUDPAddr("udp", dest.NetAddr())
+		if err != nil {
+			return nil, err
+		}
+		destAddr = addr
+	}
+
+	return client.openConnection(ctx, dest, destAddr, streamSettings, tlsConfig)
+}
+
+func init() {
+	common.Must(internet.RegisterTransportDialer(protocolName, Dial))
+}
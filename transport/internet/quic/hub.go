@@ -0,0 +1,139 @@
+package quic
+
+import (
+	"context"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/protocol/tls/cert"
+	"github.com/xtls/xray-core/common/signal/done"
+	"github.com/xtls/xray-core/transport/internet"
+	"github.com/xtls/xray-core/transport/internet/tls"
+)
+
+// Listener is an internet.Listener that listens for QUIC connections.
+type Listener struct {
+	rawConn   *sysConn
+	transport *quic.Transport
+	listener  *quic.Listener
+	done      *done.Instance
+	addConn   internet.ConnHandler
+}
+
+func (l *Listener) acceptStreams(conn quic.Connection) {
+	for {
+		stream, err := conn.AcceptStream(context.Background())
+		if err != nil {
+			newError("failed to accept stream").Base(err).AtDebug().WriteToLog()
+			select {
+			case <-conn.Context().Done():
+				return
+			case <-l.done.Wait():
+				if err := conn.CloseWithError(0, ""); err != nil {
+					newError("failed to close connection").Base(err).WriteToLog()
+				}
+				return
+			default:
+				time.Sleep(time.Second)
+				continue
+			}
+		}
+
+		l.addConn(&interConn{
+			stream: stream,
+			local:  conn.LocalAddr(),
+			remote: conn.RemoteAddr(),
+		})
+	}
+}
+
+func (l *Listener) keepAccepting() {
+	for {
+		conn, err := l.listener.Accept(context.Background())
+		if err != nil {
+			if l.done.Done() {
+				break
+			}
+			newError("failed to accept QUIC connection").Base(err).WriteToLog()
+			time.Sleep(time.Second)
+			continue
+		}
+		go l.acceptStreams(conn)
+	}
+}
+
+// Addr implements internet.Listener.Addr.
+func (l *Listener) Addr() net.Addr {
+	return l.listener.Addr()
+}
+
+// Close implements internet.Listener.Close.
+func (l *Listener) Close() error {
+	l.done.Close()
+	l.listener.Close()
+	l.transport.Close()
+	return l.rawConn.Close()
+}
+
+// Listen creates a new Listener based on configurations.
+func Listen(ctx context.Context, address net.Address, port net.Port, streamSettings *internet.MemoryStreamConfig, handler internet.ConnHandler) (internet.Listener, error) {
+	if address.Family().IsDomain() {
+		return nil, newError("domain address is not allowed for listening QUIC")
+	}
+
+	tlsConfig := tls.ConfigFromStreamSettings(streamSettings)
+	if tlsConfig == nil {
+		internalCert := tls.ParseCertificate(cert.MustGenerate(nil, cert.DNSNames(internalDomain), cert.CommonName(internalDomain)))
+		// The generated certificate has no file to be reloaded from.
+		internalCert.OneTimeLoading = true
+		tlsConfig = &tls.Config{
+			Certificate: []*tls.Certificate{internalCert},
+		}
+	}
+
+	config := streamSettings.ProtocolSettings.(*Config)
+	rawConn, err := internet.ListenSystemPacket(ctx, &net.UDPAddr{
+		IP:   address.IP(),
+		Port: int(port),
+	}, streamSettings.SocketSettings)
+	if err != nil {
+		return nil, newError("failed to listen on ", address, ":", port).Base(err)
+	}
+
+	conn, err := wrapSysConn(rawConn, config)
+	if err != nil {
+		rawConn.Close()
+		return nil, err
+	}
+	tr := &quic.Transport{
+		ConnectionIDLength: 12,
+		Conn:               conn,
+	}
+	serverTLSConfig := tlsConfig.GetTLSConfig()
+	// quic-go always sends a session ticket after the handshake, and fails if crypto/tls refuses to create one.
+	serverTLSConfig.SessionTicketsDisabled = false
+	qListener, err := tr.Listen(serverTLSConfig, config.getQUICConfig())
+	if err != nil {
+		tr.Close()
+		conn.Close()
+		return nil, err
+	}
+
+	listener := &Listener{
+		done:      done.New(),
+		rawConn:   conn,
+		transport: tr,
+		listener:  qListener,
+		addConn:   handler,
+	}
+
+	go listener.keepAccepting()
+
+	return listener, nil
+}
+
+func init() {
+	common.Must(internet.RegisterTransportListener(protocolName, Listen))
+}
@@ -0,0 +1,12 @@
+// Package quic implements a transport that maps each connection to a stream of a QUIC connection.
+//
+// Streams to the same destination share one QUIC connection when possible, so that only the
+// first stream pays for the handshake.
+package quic
+
+//go:generate go run github.com/xtls/xray-core/common/errors/errorgen
+
+const (
+	protocolName   = "quic"
+	internalDomain = "quic.internal.example.com"
+)
@@ -0,0 +1,109 @@
+package quic_test
+
+import (
+	"context"
+	"crypto/rand"
+	"io"
+	gonet "net"
+	"testing"
+	"time"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/protocol/tls/cert"
+	"github.com/xtls/xray-core/common/serial"
+	"github.com/xtls/xray-core/transport/internet"
+	"github.com/xtls/xray-core/transport/internet/headers/srtp"
+	"github.com/xtls/xray-core/transport/internet/quic"
+	"github.com/xtls/xray-core/transport/internet/stat"
+	"github.com/xtls/xray-core/transport/internet/tls"
+)
+
+func pickUDPPort() net.Port {
+	conn, err := gonet.ListenPacket("udp4", "127.0.0.1:0")
+	common.Must(err)
+	defer conn.Close()
+
+	return net.Port(conn.LocalAddr().(*gonet.UDPAddr).Port)
+}
+
+func echo(conn stat.Connection) {
+	go func() {
+		defer conn.Close()
+
+		b := buf.New()
+		defer b.Release()
+
+		for {
+			b.Clear()
+			if _, err := b.ReadFrom(conn); err != nil {
+				return
+			}
+			if _, err := conn.Write(b.Bytes()); err != nil {
+				return
+			}
+		}
+	}()
+}
+
+func testRoundTrip(t *testing.T, serverSettings *internet.MemoryStreamConfig, clientSettings *internet.MemoryStreamConfig) {
+	port := pickUDPPort()
+	listener, err := quic.Listen(context.Background(), net.LocalHostIP, port, serverSettings, echo)
+	common.Must(err)
+	defer listener.Close()
+
+	time.Sleep(time.Second)
+
+	// Streams after the first one reuse the same QUIC connection.
+	for i := 0; i < 3; i++ {
+		conn, err := quic.Dial(context.Background(), net.UDPDestination(net.LocalHostIP, port), clientSettings)
+		common.Must(err)
+
+		const N = 1024
+		b1 := make([]byte, N)
+		common.Must2(rand.Read(b1))
+		b2 := make([]byte, N)
+
+		common.Must2(conn.Write(b1))
+		common.Must2(io.ReadFull(conn, b2))
+		if string(b1) != string(b2) {
+			t.Error("unexpected response")
+		}
+
+		conn.Close()
+	}
+}
+
+func TestQuicConnection(t *testing.T) {
+	testRoundTrip(t, &internet.MemoryStreamConfig{
+		ProtocolName:     "quic",
+		ProtocolSettings: &quic.Config{},
+		SecurityType:     "tls",
+		SecuritySettings: &tls.Config{
+			Certificate: []*tls.Certificate{tls.ParseCertificate(cert.MustGenerate(nil, cert.DNSNames("www.example.com"), cert.CommonName("www.example.com")))},
+		},
+	}, &internet.MemoryStreamConfig{
+		ProtocolName:     "quic",
+		ProtocolSettings: &quic.Config{},
+		SecurityType:     "tls",
+		SecuritySettings: &tls.Config{
+			ServerName:    "www.example.com",
+			AllowInsecure: true,
+		},
+	})
+}
+
+func TestQuicConnectionWithHeader(t *testing.T) {
+	config := &quic.Config{
+		Header:          serial.ToTypedMessage(&srtp.Config{}),
+		KeepAlivePeriod: 5,
+	}
+	testRoundTrip(t, &internet.MemoryStreamConfig{
+		ProtocolName:     "quic",
+		ProtocolSettings: config,
+	}, &internet.MemoryStreamConfig{
+		ProtocolName:     "quic",
+		ProtocolSettings: config,
+	})
+}
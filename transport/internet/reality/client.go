@@ -0,0 +1,205 @@
+package reality
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	gotls "crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"time"
+
+	utls "github.com/refraction-networking/utls"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/transport/internet/tls"
+	"golang.org/x/crypto/hkdf"
+	"golang.org/x/net/http2"
+)
+
+// UConn is a REALITY client connection.
+type UConn struct {
+	*utls.UConn
+	ServerName string
+	AuthKey    []byte
+	Verified   bool
+}
+
+func (c *UConn) HandshakeAddress() net.Address {
+	if err := c.Handshake(); err != nil {
+		return nil
+	}
+	state := c.ConnectionState()
+	if state.ServerName == "" {
+		return nil
+	}
+	return net.ParseAddress(state.ServerName)
+}
+
+func (c *UConn) NegotiatedProtocol() (name string, mutual bool) {
+	state := c.ConnectionState()
+	return state.NegotiatedProtocol, state.NegotiatedProtocolIsMutual
+}
+
+// VerifyPeerCertificate accepts the temporary certificate of a REALITY server.
+// Any other certificate must be valid for ServerName, so that the handshake with
+// a real dest looks like the one of an ordinary browser.
+func (c *UConn) VerifyPeerCertificate(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+	if len(rawCerts) == 0 {
+		return newError("REALITY: no peer certificate")
+	}
+	certs := make([]*x509.Certificate, len(rawCerts))
+	for i, rawCert := range rawCerts {
+		cert, err := x509.ParseCertificate(rawCert)
+		if err != nil {
+			return err
+		}
+		certs[i] = cert
+	}
+	h := hmac.New(sha512.New, c.AuthKey)
+	h.Write(certs[0].RawSubjectPublicKeyInfo)
+	if hmac.Equal(h.Sum(nil), certs[0].Signature) {
+		c.Verified = true
+		return nil
+	}
+	opts := x509.VerifyOptions{
+		DNSName:       c.ServerName,
+		Intermediates: x509.NewCertPool(),
+	}
+	for _, cert := range certs[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	_, err := certs[0].Verify(opts)
+	return err
+}
+
+// UClient performs a REALITY client handshake on c. If the server turns out not to
+// be a REALITY server, the connection is used to browse it briefly and an error is
+// returned.
+func UClient(c net.Conn, config *Config, ctx context.Context, dest net.Destination) (net.Conn, error) {
+	fingerprint := tls.GetFingerprint(config.Fingerprint)
+	if fingerprint == nil {
+		return nil, newError("REALITY: unknown fingerprint ", config.Fingerprint)
+	}
+
+	uConn := &UConn{
+		ServerName: config.ServerName,
+	}
+	if uConn.ServerName == "" {
+		uConn.ServerName = dest.Address.String()
+	}
+	var err error
+	if uConn.UConn, err = tls.NewUConn(c, &utls.Config{
+		ServerName:             uConn.ServerName,
+		InsecureSkipVerify:     true,
+		VerifyPeerCertificate:  uConn.VerifyPeerCertificate,
+		SessionTicketsDisabled: true,
+	}, fingerprint); err != nil {
+		return nil, err
+	}
+	if err := uConn.BuildHandshakeState(); err != nil {
+		return nil, err
+	}
+
+	hello := uConn.HandshakeState.Hello
+	if len(hello.SessionId) != 32 || len(hello.Raw) < 39+32 {
+		return nil, newError("REALITY: fingerprint ", config.Fingerprint, " has no 32-byte session id")
+	}
+	hello.SessionId = make([]byte, 32)
+	copy(hello.Raw[39:], hello.SessionId) // the fixed location of the session id
+	hello.SessionId[0] = core.Version_x
+	hello.SessionId[1] = core.Version_y
+	hello.SessionId[2] = core.Version_z
+	binary.BigEndian.PutUint32(hello.SessionId[4:], uint32(time.Now().Unix()))
+	copy(hello.SessionId[8:], config.ShortId)
+
+	publicKey, err := ecdh.X25519().NewPublicKey(config.PublicKey)
+	if err != nil {
+		return nil, newError("REALITY: invalid public key").Base(err)
+	}
+	ecdhe := uConn.HandshakeState.State13.EcdheKey
+	if params := uConn.HandshakeState.State13.KeySharesParams; params != nil {
+		if key, ok := params.GetEcdheKey(utls.X25519); ok {
+			ecdhe = key
+		}
+	}
+	if ecdhe == nil || ecdhe.Curve() != ecdh.X25519() {
+		return nil, newError("REALITY: fingerprint ", config.Fingerprint, " has no X25519 key share")
+	}
+	if uConn.AuthKey, err = ecdhe.ECDH(publicKey); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(hkdf.New(sha256.New, uConn.AuthKey, hello.Random[:20], []byte("REALITY")), uConn.AuthKey); err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(uConn.AuthKey)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	aead.Seal(hello.SessionId[:0], hello.Random[20:], hello.SessionId[:16], hello.Raw)
+	copy(hello.Raw[39:], hello.SessionId)
+
+	if err := uConn.HandshakeContext(ctx); err != nil {
+		return nil, err
+	}
+	if config.Show {
+		newError("REALITY handshake with ", dest, " verified: ", uConn.Verified).AtInfo().WriteToLog(session.ExportIDToError(ctx))
+	}
+	if !uConn.Verified {
+		go spider(uConn, config.SpiderX, fingerprint.Client)
+		return nil, newError("REALITY: processed invalid connection from ", dest).AtWarning()
+	}
+	return uConn, nil
+}
+
+// spider requests spiderX from the server the connection was actually made to, then
+// closes the connection, so it ends like a short visit of the site.
+func spider(uConn *UConn, spiderX string, userAgent string) {
+	defer uConn.Close()
+
+	if spiderX == "" {
+		spiderX = "/"
+	}
+	dialTLS := func(context.Context, string, string) (net.Conn, error) {
+		return uConn, nil
+	}
+	var transport http.RoundTripper
+	if uConn.ConnectionState().NegotiatedProtocol == "h2" {
+		transport = &http2.Transport{
+			DialTLSContext: func(ctx context.Context, network, addr string, _ *gotls.Config) (net.Conn, error) {
+				return dialTLS(ctx, network, addr)
+			},
+		}
+	} else {
+		transport = &http.Transport{
+			DialTLSContext:    dialTLS,
+			DisableKeepAlives: true,
+		}
+	}
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   handshakeTimeout,
+	}
+	req, err := http.NewRequest("GET", "https://"+uConn.ServerName+spiderX, nil)
+	if err != nil {
+		return
+	}
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := client.Do(req)
+	if err != nil {
+		return
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+}
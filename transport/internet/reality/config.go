@@ -0,0 +1,44 @@
+package reality
+
+import (
+	"time"
+
+	"github.com/xtls/xray-core/transport/internet"
+)
+
+// GetREALITYConfig returns the runtime state of a server side Config.
+func (c *Config) GetREALITYConfig() *ServerConfig {
+	config := &ServerConfig{
+		Show:        c.Show,
+		Type:        c.Type,
+		Dest:        c.Dest,
+		Xver:        byte(c.Xver),
+		PrivateKey:  c.PrivateKey,
+		MaxTimeDiff: time.Duration(c.MaxTimeDiff) * time.Millisecond,
+		ServerNames: make(map[string]bool),
+		ShortIds:    make(map[[8]byte]bool),
+	}
+	if config.Type == "" {
+		config.Type = "tcp"
+	}
+	for _, serverName := range c.ServerNames {
+		config.ServerNames[serverName] = true
+	}
+	for _, shortId := range c.ShortIds {
+		var id [8]byte
+		copy(id[:], shortId)
+		config.ShortIds[id] = true
+	}
+	return config
+}
+
+func ConfigFromStreamSettings(settings *internet.MemoryStreamConfig) *Config {
+	if settings == nil {
+		return nil
+	}
+	config, ok := settings.SecuritySettings.(*Config)
+	if !ok {
+		return nil
+	}
+	return config
+}
@@ -0,0 +1,10 @@
+// Package reality implements the REALITY security layer.
+//
+// A REALITY server needs no certificate of its own. Clients prove that they know
+// the server's X25519 public key by sealing a short id into the session id of their
+// ClientHello. Authenticated clients finish a TLS 1.3 handshake with a temporary
+// certificate bound to that proof, while every other handshake is relayed to dest
+// untouched, so probes only ever see the site being borrowed.
+package reality
+
+//go:generate go run github.com/xtls/xray-core/common/errors/errorgen
@@ -0,0 +1,149 @@
+package reality_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	gotls "crypto/tls"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/protocol/tls/cert"
+	. "github.com/xtls/xray-core/transport/internet/reality"
+	"golang.org/x/crypto/curve25519"
+)
+
+const serverName = "www.example.com"
+
+// startDest starts a TLS server standing in for the site being borrowed. The
+// server names of the handshakes it receives are sent to the returned channel.
+func startDest(t *testing.T) (string, <-chan string) {
+	certPEM, keyPEM := cert.MustGenerate(nil, cert.DNSNames(serverName)).ToPEM()
+	certificate, err := gotls.X509KeyPair(certPEM, keyPEM)
+	common.Must(err)
+
+	names := make(chan string, 1)
+	listener, err := gotls.Listen("tcp", "127.0.0.1:0", &gotls.Config{
+		Certificates: []gotls.Certificate{certificate},
+		GetConfigForClient: func(info *gotls.ClientHelloInfo) (*gotls.Config, error) {
+			names <- info.ServerName
+			return nil, nil
+		},
+	})
+	common.Must(err)
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				conn.(*gotls.Conn).Handshake()
+			}()
+		}
+	}()
+	return listener.Addr().String(), names
+}
+
+func startServer(t *testing.T, config *Config) net.Destination {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	common.Must(err)
+	t.Cleanup(func() { listener.Close() })
+
+	serverConfig := config.GetREALITYConfig()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				realityConn, err := Server(conn, serverConfig)
+				if err != nil {
+					return
+				}
+				defer realityConn.Close()
+				io.Copy(realityConn, realityConn)
+			}()
+		}
+	}()
+	return net.DestinationFromAddr(listener.Addr())
+}
+
+func generateKeyPair() ([]byte, []byte) {
+	privateKey := make([]byte, curve25519.ScalarSize)
+	common.Must2(rand.Read(privateKey))
+	publicKey, err := curve25519.X25519(privateKey, curve25519.Basepoint)
+	common.Must(err)
+	return privateKey, publicKey
+}
+
+func TestRealityHandshake(t *testing.T) {
+	privateKey, publicKey := generateKeyPair()
+	destAddr, _ := startDest(t)
+	dest := startServer(t, &Config{
+		Dest:        destAddr,
+		ServerNames: []string{serverName},
+		PrivateKey:  privateKey,
+		MaxTimeDiff: 60000,
+		ShortIds:    [][]byte{{0x0a, 0x1b}},
+	})
+
+	rawConn, err := net.Dial("tcp", dest.NetAddr())
+	common.Must(err)
+	conn, err := UClient(rawConn, &Config{
+		Fingerprint: "chrome",
+		ServerName:  serverName,
+		PublicKey:   publicKey,
+		ShortId:     []byte{0x0a, 0x1b},
+	}, context.Background(), dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	payload := make([]byte, 4096)
+	common.Must2(rand.Read(payload))
+	common.Must2(conn.Write(payload))
+	response := make([]byte, len(payload))
+	common.Must2(io.ReadFull(conn, response))
+	if !bytes.Equal(payload, response) {
+		t.Error("unexpected response")
+	}
+}
+
+func TestRealityRelaysUnauthenticated(t *testing.T) {
+	privateKey, publicKey := generateKeyPair()
+	destAddr, names := startDest(t)
+	dest := startServer(t, &Config{
+		Dest:        destAddr,
+		ServerNames: []string{serverName},
+		PrivateKey:  privateKey,
+		ShortIds:    [][]byte{{0x0a, 0x1b}},
+	})
+
+	rawConn, err := net.Dial("tcp", dest.NetAddr())
+	common.Must(err)
+	if _, err := UClient(rawConn, &Config{
+		Fingerprint: "chrome",
+		ServerName:  serverName,
+		PublicKey:   publicKey,
+		ShortId:     []byte{0xff},
+	}, context.Background(), dest); err == nil {
+		t.Fatal("expected handshake with an unknown short id to fail")
+	}
+
+	select {
+	case name := <-names:
+		if name != serverName {
+			t.Error("unexpected server name at dest: ", name)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("handshake was not relayed to dest")
+	}
+}
@@ -0,0 +1,380 @@
+package reality
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	gotls "crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"io"
+	"math/big"
+	"time"
+
+	"github.com/pires/go-proxyproto"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/net"
+	"golang.org/x/crypto/cryptobyte"
+	"golang.org/x/crypto/cryptobyte/asn1"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	recordTypeHandshake        = 22
+	typeClientHello            = 1
+	extensionServerName        = 0
+	extensionSupportedVersions = 43
+	extensionKeyShare          = 51
+	x25519                     = 0x001d
+
+	maxClientHelloSize = 64 * 1024
+	handshakeTimeout   = 8 * time.Second
+)
+
+var (
+	certKey       *ecdsa.PrivateKey
+	certPublicKey []byte
+	signedCert    []byte
+)
+
+// The temporary certificate uses a P-256 key, as every fingerprint offers ECDSA
+// signatures. Its signature is replaced by a 64-byte HMAC for each handshake, so
+// the certificate is rebuilt around a signature of that fixed size.
+func init() {
+	var err error
+	certKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	common.Must(err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(0),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, certKey.Public(), certKey)
+	common.Must(err)
+	parsed, err := x509.ParseCertificate(der)
+	common.Must(err)
+	certPublicKey = parsed.RawSubjectPublicKeyInfo
+
+	var certificate, tbs, signatureAlgorithm cryptobyte.String
+	input := cryptobyte.String(der)
+	if !input.ReadASN1(&certificate, asn1.SEQUENCE) ||
+		!certificate.ReadASN1Element(&tbs, asn1.SEQUENCE) ||
+		!certificate.ReadASN1Element(&signatureAlgorithm, asn1.SEQUENCE) {
+		panic("reality: failed to parse the temporary certificate")
+	}
+	b := cryptobyte.NewBuilder(nil)
+	b.AddASN1(asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddBytes(tbs)
+		b.AddBytes(signatureAlgorithm)
+		b.AddASN1BitString(make([]byte, 64))
+	})
+	signedCert = b.BytesOrPanic()
+}
+
+// ServerConfig is the runtime form of a server side Config.
+type ServerConfig struct {
+	Show        bool
+	Type        string
+	Dest        string
+	Xver        byte
+	PrivateKey  []byte
+	MaxTimeDiff time.Duration
+	ServerNames map[string]bool
+	ShortIds    map[[8]byte]bool
+}
+
+// Conn is a REALITY connection accepted by Server.
+type Conn struct {
+	*gotls.Conn
+	raw net.Conn
+}
+
+// NetConn returns the underlying raw connection. It must not be used before the
+// handshake is complete, because the ClientHello is replayed from a buffer until then.
+func (c *Conn) NetConn() net.Conn {
+	return c.raw
+}
+
+func (c *Conn) HandshakeAddress() net.Address {
+	if err := c.Handshake(); err != nil {
+		return nil
+	}
+	state := c.ConnectionState()
+	if state.ServerName == "" {
+		return nil
+	}
+	return net.ParseAddress(state.ServerName)
+}
+
+func (c *Conn) NegotiatedProtocol() (name string, mutual bool) {
+	state := c.ConnectionState()
+	return state.NegotiatedProtocol, state.NegotiatedProtocolIsMutual
+}
+
+// Server reads the ClientHello from c and completes the handshake if the client is
+// authenticated. Otherwise the whole connection is relayed to config.Dest in the
+// background and an error is returned.
+func Server(c net.Conn, config *ServerConfig) (*Conn, error) {
+	if err := c.SetReadDeadline(time.Now().Add(handshakeTimeout)); err != nil {
+		return nil, err
+	}
+	raw, hello, err := readClientHello(c)
+	c.SetReadDeadline(time.Time{})
+	replay := &replayConn{Conn: c, reader: io.MultiReader(bytes.NewReader(raw), c)}
+	if err != nil {
+		go relay(replay, config)
+		return nil, newError("failed to read ClientHello from ", c.RemoteAddr()).Base(err)
+	}
+
+	authKey, err := config.authenticate(hello)
+	if err != nil {
+		go relay(replay, config)
+		return nil, newError("relayed handshake from ", c.RemoteAddr(), " to ", config.Dest).Base(err)
+	}
+	if config.Show {
+		newError("authenticated ", c.RemoteAddr(), " with server name ", hello.serverName).AtInfo().WriteToLog()
+	}
+
+	cert := make([]byte, len(signedCert))
+	copy(cert, signedCert)
+	h := hmac.New(sha512.New, authKey)
+	h.Write(certPublicKey)
+	h.Sum(cert[:len(cert)-64])
+
+	conn := gotls.Server(replay, &gotls.Config{
+		MinVersion: gotls.VersionTLS13,
+		Certificates: []gotls.Certificate{{
+			Certificate: [][]byte{cert},
+			PrivateKey:  certKey,
+		}},
+		SessionTicketsDisabled: true,
+	})
+	if err := conn.Handshake(); err != nil {
+		conn.Close()
+		return nil, newError("failed to complete REALITY handshake").Base(err)
+	}
+	return &Conn{Conn: conn, raw: c}, nil
+}
+
+// authenticate decrypts the session id of hello and returns the shared auth key if
+// it carries a valid short id within the allowed time difference.
+func (config *ServerConfig) authenticate(hello *clientHello) ([]byte, error) {
+	if !config.ServerNames[hello.serverName] {
+		return nil, newError("server name not allowed: ", hello.serverName)
+	}
+	if !hello.tls13 || hello.keyShare == nil || len(hello.sessionID) != 32 {
+		return nil, newError("not a TLS 1.3 ClientHello with an X25519 key share")
+	}
+
+	authKey, err := curve25519.X25519(config.PrivateKey, hello.keyShare)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(hkdf.New(sha256.New, authKey, hello.random[:20], []byte("REALITY")), authKey); err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(authKey)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	plainText, err := aead.Open(nil, hello.random[20:], hello.sessionID, hello.aad)
+	if err != nil {
+		return nil, newError("failed to decrypt session id").Base(err)
+	}
+
+	if config.MaxTimeDiff != 0 {
+		clientTime := time.Unix(int64(binary.BigEndian.Uint32(plainText[4:8])), 0)
+		if diff := time.Since(clientTime); diff > config.MaxTimeDiff || diff < -config.MaxTimeDiff {
+			return nil, newError("client time out of range: ", clientTime)
+		}
+	}
+	var shortID [8]byte
+	copy(shortID[:], plainText[8:16])
+	if !config.ShortIds[shortID] {
+		return nil, newError("unknown short id")
+	}
+	return authKey, nil
+}
+
+func relay(conn *replayConn, config *ServerConfig) {
+	defer conn.Close()
+
+	target, err := (&net.Dialer{Timeout: handshakeTimeout}).Dial(config.Type, config.Dest)
+	if err != nil {
+		newError("failed to dial REALITY dest ", config.Dest).Base(err).AtWarning().WriteToLog()
+		return
+	}
+	defer target.Close()
+
+	if config.Xver == 1 || config.Xver == 2 {
+		if _, err := proxyproto.HeaderProxyFromAddrs(config.Xver, conn.RemoteAddr(), conn.LocalAddr()).WriteTo(target); err != nil {
+			newError("failed to write PROXY protocol header to ", config.Dest).Base(err).AtWarning().WriteToLog()
+			return
+		}
+	}
+
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(target, conn)
+		if tcpConn, ok := target.(*net.TCPConn); ok {
+			tcpConn.CloseWrite()
+		}
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(conn, target)
+		done <- struct{}{}
+	}()
+	<-done
+	<-done
+}
+
+// replayConn reads the buffered ClientHello before the rest of Conn.
+type replayConn struct {
+	net.Conn
+	reader io.Reader
+}
+
+func (c *replayConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
+
+type clientHello struct {
+	random     []byte
+	sessionID  []byte
+	serverName string
+	keyShare   []byte
+	tls13      bool
+	// aad is the ClientHello handshake message with the session id zeroed.
+	aad []byte
+}
+
+// readClientHello reads TLS records from r until a complete ClientHello message has
+// been received. It returns every byte read, even on error.
+func readClientHello(r io.Reader) ([]byte, *clientHello, error) {
+	var raw, message []byte
+	header := make([]byte, 5)
+	for len(message) < 4 || len(message) < 4+handshakeLength(message) {
+		if _, err := io.ReadFull(r, header); err != nil {
+			return append(raw, header...), nil, err
+		}
+		raw = append(raw, header...)
+		if header[0] != recordTypeHandshake {
+			return raw, nil, newError("not a handshake record")
+		}
+		length := int(binary.BigEndian.Uint16(header[3:]))
+		if len(raw)+length > maxClientHelloSize {
+			return raw, nil, newError("ClientHello too large")
+		}
+		fragment := make([]byte, length)
+		n, err := io.ReadFull(r, fragment)
+		raw = append(raw, fragment[:n]...)
+		if err != nil {
+			return raw, nil, err
+		}
+		message = append(message, fragment...)
+	}
+	if message[0] != typeClientHello {
+		return raw, nil, newError("not a ClientHello")
+	}
+	message = message[:4+handshakeLength(message)]
+
+	hello, ok := parseClientHello(message)
+	if !ok {
+		return raw, nil, newError("malformed ClientHello")
+	}
+	return raw, hello, nil
+}
+
+func handshakeLength(message []byte) int {
+	return int(message[1])<<16 | int(message[2])<<8 | int(message[3])
+}
+
+func parseClientHello(message []byte) (*clientHello, bool) {
+	hello := new(clientHello)
+	s := cryptobyte.String(message[4:])
+	var version uint16
+	var sessionID, cipherSuites, compressionMethods, extensions cryptobyte.String
+	if !s.ReadUint16(&version) || !s.ReadBytes(&hello.random, 32) ||
+		!s.ReadUint8LengthPrefixed(&sessionID) ||
+		!s.ReadUint16LengthPrefixed(&cipherSuites) ||
+		!s.ReadUint8LengthPrefixed(&compressionMethods) {
+		return nil, false
+	}
+	hello.sessionID = sessionID
+	hello.aad = make([]byte, len(message))
+	copy(hello.aad, message)
+	if len(sessionID) == 32 {
+		copy(hello.aad[39:71], make([]byte, 32))
+	}
+	if s.Empty() {
+		return hello, true
+	}
+	if !s.ReadUint16LengthPrefixed(&extensions) || !s.Empty() {
+		return nil, false
+	}
+
+	for !extensions.Empty() {
+		var extension uint16
+		var data cryptobyte.String
+		if !extensions.ReadUint16(&extension) || !extensions.ReadUint16LengthPrefixed(&data) {
+			return nil, false
+		}
+		switch extension {
+		case extensionServerName:
+			var names cryptobyte.String
+			if !data.ReadUint16LengthPrefixed(&names) {
+				return nil, false
+			}
+			for !names.Empty() {
+				var nameType uint8
+				var name cryptobyte.String
+				if !names.ReadUint8(&nameType) || !names.ReadUint16LengthPrefixed(&name) {
+					return nil, false
+				}
+				if nameType == 0 {
+					hello.serverName = string(name)
+				}
+			}
+		case extensionSupportedVersions:
+			var versions cryptobyte.String
+			if !data.ReadUint8LengthPrefixed(&versions) {
+				return nil, false
+			}
+			for !versions.Empty() {
+				var v uint16
+				if !versions.ReadUint16(&v) {
+					return nil, false
+				}
+				if v == gotls.VersionTLS13 {
+					hello.tls13 = true
+				}
+			}
+		case extensionKeyShare:
+			var shares cryptobyte.String
+			if !data.ReadUint16LengthPrefixed(&shares) {
+				return nil, false
+			}
+			for !shares.Empty() {
+				var group uint16
+				var key cryptobyte.String
+				if !shares.ReadUint16(&group) || !shares.ReadUint16LengthPrefixed(&key) {
+					return nil, false
+				}
+				if group == x25519 && len(key) == curve25519.PointSize {
+					hello.keyShare = key
+				}
+			}
+		}
+	}
+	return hello, true
+}
@@ -250,3 +250,7 @@ func getInterfaceIndexByName(name string) int {
 	}
 	return 0
 }
+
+func setFreebind(fd uintptr, ipv6 bool) error {
+	return nil
+}
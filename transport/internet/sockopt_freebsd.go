@@ -262,3 +262,7 @@ func setReusePort(fd uintptr) error {
 	}
 	return nil
 }
+
+func setFreebind(fd uintptr, ipv6 bool) error {
+	return nil
+}
@@ -213,3 +213,18 @@ func setReusePort(fd uintptr) error {
 	}
 	return nil
 }
+
+// setFreebind allows binding to addresses that are not assigned to any
+// interface, such as addresses of a routed IPv6 prefix.
+func setFreebind(fd uintptr, ipv6 bool) error {
+	if ipv6 {
+		if err := syscall.SetsockoptInt(int(fd), syscall.SOL_IPV6, unix.IPV6_FREEBIND, 1); err != nil {
+			return newError("failed to set IPV6_FREEBIND").Base(err)
+		}
+		return nil
+	}
+	if err := syscall.SetsockoptInt(int(fd), syscall.SOL_IP, unix.IP_FREEBIND, 1); err != nil {
+		return newError("failed to set IP_FREEBIND").Base(err)
+	}
+	return nil
+}
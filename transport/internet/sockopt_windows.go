@@ -74,3 +74,7 @@ func setReuseAddr(fd uintptr) error {
 func setReusePort(fd uintptr) error {
 	return nil
 }
+
+func setFreebind(fd uintptr, ipv6 bool) error {
+	return nil
+}
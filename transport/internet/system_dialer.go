@@ -2,6 +2,7 @@ package internet
 
 import (
 	"context"
+	gonet "net"
 	"syscall"
 	"time"
 
@@ -44,9 +45,28 @@ func hasBindAddr(sockopt *SocketConfig) bool {
 	return sockopt != nil && len(sockopt.BindAddress) > 0 && sockopt.BindPort > 0
 }
 
+// needsFreebind reports whether src is an IP address that is not assigned to
+// any local interface, such as an address of a routed IPv6 prefix.
+func needsFreebind(src net.Address) bool {
+	if src == nil || src == net.AnyIP || !src.Family().IsIP() {
+		return false
+	}
+	addrs, err := gonet.InterfaceAddrs()
+	if err != nil {
+		return false
+	}
+	for _, addr := range addrs {
+		if prefix, ok := addr.(*net.IPNet); ok && prefix.IP.Equal(src.IP()) {
+			return false
+		}
+	}
+	return true
+}
+
 func (d *DefaultSystemDialer) Dial(ctx context.Context, src net.Address, dest net.Destination, sockopt *SocketConfig) (net.Conn, error) {
 	newError("dialing to " + dest.String()).AtDebug().WriteToLog()
 
+	freebind := needsFreebind(src)
 	if dest.Network == net.Network_UDP && !hasBindAddr(sockopt) {
 		srcAddr := resolveSrcAddr(net.Network_UDP, src)
 		if srcAddr == nil {
@@ -55,7 +75,14 @@ func (d *DefaultSystemDialer) Dial(ctx context.Context, src net.Address, dest ne
 				Port: 0,
 			}
 		}
-		packetConn, err := ListenSystemPacket(ctx, srcAddr, sockopt)
+		var packetConn net.PacketConn
+		var err error
+		if freebind {
+			lc := net.ListenConfig{Control: freebindControl(ctx, src, getControlFunc(ctx, sockopt, effectiveListener.controllers))}
+			packetConn, err = lc.ListenPacket(ctx, srcAddr.Network(), srcAddr.String())
+		} else {
+			packetConn, err = ListenSystemPacket(ctx, srcAddr, sockopt)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -78,7 +105,7 @@ func (d *DefaultSystemDialer) Dial(ctx context.Context, src net.Address, dest ne
 		KeepAlive: goStdKeepAlive,
 	}
 
-	if sockopt != nil || len(d.controllers) > 0 {
+	if sockopt != nil || len(d.controllers) > 0 || freebind {
 		if sockopt != nil && sockopt.TcpMptcp {
 			dialer.SetMultipathTCP(true)
 		}
@@ -89,6 +116,11 @@ func (d *DefaultSystemDialer) Dial(ctx context.Context, src net.Address, dest ne
 				}
 			}
 			return c.Control(func(fd uintptr) {
+				if freebind {
+					if err := setFreebind(fd, src.Family().IsIPv6()); err != nil {
+						newError("failed to allow binding to ", src).Base(err).AtDebug().WriteToLog(session.ExportIDToError(ctx))
+					}
+				}
 				if sockopt != nil {
 					if err := applyOutboundSocketOptions(network, address, fd, sockopt); err != nil {
 						newError("failed to apply socket options").Base(err).WriteToLog(session.ExportIDToError(ctx))
@@ -106,6 +138,21 @@ func (d *DefaultSystemDialer) Dial(ctx context.Context, src net.Address, dest ne
 	return dialer.DialContext(ctx, dest.Network.SystemString(), dest.NetAddr())
 }
 
+// freebindControl wraps control to allow binding to src, which is not
+// assigned to any interface.
+func freebindControl(ctx context.Context, src net.Address, control func(network, address string, c syscall.RawConn) error) func(network, address string, c syscall.RawConn) error {
+	return func(network, address string, c syscall.RawConn) error {
+		if err := control(network, address, c); err != nil {
+			return err
+		}
+		return c.Control(func(fd uintptr) {
+			if err := setFreebind(fd, src.Family().IsIPv6()); err != nil {
+				newError("failed to allow binding to ", address).Base(err).AtDebug().WriteToLog(session.ExportIDToError(ctx))
+			}
+		})
+	}
+}
+
 type PacketConnWrapper struct {
 	Conn net.PacketConn
 	Dest net.Addr
@@ -18,6 +18,9 @@ import (
 
 var globalSessionCache = tls.NewLRUClientSessionCache(128)
 
+// certsAccess guards the certificate slices that are refreshed by hot reload.
+var certsAccess sync.RWMutex
+
 // ParseCertificate converts a cert.Certificate to Certificate.
 func ParseCertificate(c *cert.Certificate) *Certificate {
 	if c != nil {
@@ -40,6 +43,19 @@ func (c *Config) loadSelfCertPool() (*x509.CertPool, error) {
 	return root, nil
 }
 
+func (c *Config) loadClientCertPool() (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	for _, cert := range c.Certificate {
+		if cert.Usage != Certificate_AUTHORITY_VERIFY {
+			continue
+		}
+		if !pool.AppendCertsFromPEM(cert.Certificate) {
+			return nil, newError("failed to append client CA cert").AtWarning()
+		}
+	}
+	return pool, nil
+}
+
 // BuildCertificates builds a list of TLS certificates from proto definition.
 func (c *Config) BuildCertificates() []*tls.Certificate {
 	certs := make([]*tls.Certificate, 0, len(c.Certificate))
@@ -101,10 +117,16 @@ func (c *Config) BuildCertificates() []*tls.Certificate {
 						if newOCSPData, err := ocsp.GetOCSPForCert(cert.Certificate); err != nil {
 							newError("ignoring invalid OCSP").Base(err).AtWarning().WriteToLog()
 						} else if string(newOCSPData) != string(cert.OCSPStaple) {
-							cert.OCSPStaple = newOCSPData
+							stapled := *cert
+							stapled.OCSPStaple = newOCSPData
+							cert = &stapled
 						}
 					}
-					certs[index] = cert
+					if certs[index] != cert {
+						certsAccess.Lock()
+						certs[index] = cert
+						certsAccess.Unlock()
+					}
 					<-t.C
 				}
 			}(entry, certs[index], index)
@@ -227,6 +249,8 @@ func getNewGetCertificateFunc(certs []*tls.Certificate, rejectUnknownSNI bool) f
 		if len(certs) == 0 {
 			return nil, errNoCertificates
 		}
+		certsAccess.RLock()
+		defer certsAccess.RUnlock()
 		sni := strings.ToLower(hello.ServerName)
 		if !rejectUnknownSNI && (len(certs) == 1 || sni == "") {
 			return certs[0], nil
@@ -252,6 +276,17 @@ func getNewGetCertificateFunc(certs []*tls.Certificate, rejectUnknownSNI bool) f
 	}
 }
 
+func getClientCertificateFunc(certs []*tls.Certificate) func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+		if len(certs) == 0 {
+			return new(tls.Certificate), nil
+		}
+		certsAccess.RLock()
+		defer certsAccess.RUnlock()
+		return certs[0], nil
+	}
+}
+
 func (c *Config) parseServerName() string {
 	return c.ServerName
 }
@@ -313,11 +348,36 @@ func (c *Config) GetTLSConfig(opts ...Option) *tls.Config {
 		opt(config)
 	}
 
+	if len(c.SessionTicketKeyFiles) > 0 {
+		config.SessionTicketsDisabled = false
+		keys := newSessionTicketKeys(c)
+		keys.update(config, time.Now())
+		config.GetConfigForClient = keys.getConfigForClientFunc(config)
+	}
+
 	caCerts := c.getCustomCA()
 	if len(caCerts) > 0 {
 		config.GetCertificate = getGetCertificateFunc(config, caCerts)
 	} else {
-		config.GetCertificate = getNewGetCertificateFunc(c.BuildCertificates(), c.RejectUnknownSni)
+		certs := c.BuildCertificates()
+		config.GetCertificate = getNewGetCertificateFunc(certs, c.RejectUnknownSni)
+		config.GetClientCertificate = getClientCertificateFunc(certs)
+	}
+
+	switch c.ClientAuth {
+	case Config_REQUIRE_AND_VERIFY_CLIENT_CERT:
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	case Config_VERIFY_CLIENT_CERT_IF_GIVEN:
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	if config.ClientAuth != tls.NoClientCert {
+		pool, err := c.loadClientCertPool()
+		if err != nil {
+			newError("failed to load client CA certificates").AtError().Base(err).WriteToLog()
+			// A nil pool would fall back to the system roots.
+			pool = x509.NewCertPool()
+		}
+		config.ClientCAs = pool
 	}
 
 	if sn := c.parseServerName(); len(sn) > 0 {
@@ -388,6 +448,21 @@ func WithNextProto(protocol ...string) Option {
 	}
 }
 
+// LookupClientUser returns the user of the identity of a client certificate.
+// Without configured users, every identity is a user of level 0 whose email
+// is the identity.
+func (c *Config) LookupClientUser(identity string) (*ClientUser, error) {
+	if len(c.ClientUser) == 0 {
+		return &ClientUser{Identity: identity, Email: identity}, nil
+	}
+	for _, user := range c.ClientUser {
+		if user.Identity == identity {
+			return user, nil
+		}
+	}
+	return nil, newError("unknown client certificate: ", identity)
+}
+
 // ConfigFromStreamSettings fetches Config from stream settings. Nil if not found.
 func ConfigFromStreamSettings(settings *internet.MemoryStreamConfig) *Config {
 	if settings == nil {
@@ -1,8 +1,11 @@
 package tls_test
 
 import (
+	"context"
 	gotls "crypto/tls"
 	"crypto/x509"
+	"io"
+	"net"
 	"testing"
 	"time"
 
@@ -72,6 +75,80 @@ func TestInsecureCertificates(t *testing.T) {
 	}
 }
 
+func TestClientCertificate(t *testing.T) {
+	extKeyUsage := func(usage ...x509.ExtKeyUsage) cert.Option {
+		return func(c *x509.Certificate) {
+			c.ExtKeyUsage = usage
+		}
+	}
+	caCert := cert.MustGenerate(nil, cert.Authority(true), cert.KeyUsage(x509.KeyUsageCertSign), extKeyUsage(x509.ExtKeyUsageAny))
+	serverCert := cert.MustGenerate(caCert, cert.CommonName("www.example.com"), cert.DNSNames("www.example.com"))
+	clientCert := cert.MustGenerate(caCert, cert.CommonName("laptop"), extKeyUsage(x509.ExtKeyUsageClientAuth))
+	otherCert := cert.MustGenerate(nil, cert.CommonName("laptop"), extKeyUsage(x509.ExtKeyUsageClientAuth))
+
+	ca := ParseCertificate(caCert)
+	ca.Usage = Certificate_AUTHORITY_VERIFY
+	ca.OneTimeLoading = true
+	server := ParseCertificate(serverCert)
+	server.OneTimeLoading = true
+
+	handshake := func(clientAuth Config_ClientAuth, client *cert.Certificate) (string, error) {
+		serverConfig := (&Config{
+			Certificate: []*Certificate{server, ca},
+			ClientAuth:  clientAuth,
+		}).GetTLSConfig()
+		clientConfig := &Config{
+			Certificate:       []*Certificate{ca},
+			DisableSystemRoot: true,
+			ServerName:        "www.example.com",
+		}
+		if client != nil {
+			c := ParseCertificate(client)
+			c.OneTimeLoading = true
+			clientConfig.Certificate = append(clientConfig.Certificate, c)
+		}
+
+		serverConn, clientConn := net.Pipe()
+		defer serverConn.Close()
+		defer clientConn.Close()
+		go io.Copy(io.Discard, Client(clientConn, clientConfig.GetTLSConfig()))
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+		defer cancel()
+		return Server(serverConn, serverConfig).(*Conn).ClientIdentity(ctx)
+	}
+
+	if identity, err := handshake(Config_REQUIRE_AND_VERIFY_CLIENT_CERT, clientCert); err != nil || identity != "laptop" {
+		t.Error("identity: ", identity, ", error: ", err)
+	}
+	if _, err := handshake(Config_REQUIRE_AND_VERIFY_CLIENT_CERT, otherCert); err == nil {
+		t.Error("expected an error for a certificate of an unknown CA")
+	}
+	if _, err := handshake(Config_REQUIRE_AND_VERIFY_CLIENT_CERT, nil); err == nil {
+		t.Error("expected an error without a client certificate")
+	}
+	if identity, err := handshake(Config_VERIFY_CLIENT_CERT_IF_GIVEN, nil); err != nil || identity != "" {
+		t.Error("identity: ", identity, ", error: ", err)
+	}
+}
+
+func TestLookupClientUser(t *testing.T) {
+	if user, err := (&Config{}).LookupClientUser("laptop"); err != nil || user.Email != "laptop" || user.Level != 0 {
+		t.Error("user: ", user, ", error: ", err)
+	}
+
+	config := &Config{
+		ClientUser: []*ClientUser{
+			{Identity: "laptop", Email: "alice@example.com", Level: 2},
+		},
+	}
+	if user, err := config.LookupClientUser("laptop"); err != nil || user.Email != "alice@example.com" || user.Level != 2 {
+		t.Error("user: ", user, ", error: ", err)
+	}
+	if _, err := config.LookupClientUser("phone"); err == nil {
+		t.Error("expected an error for an unknown identity")
+	}
+}
+
 func BenchmarkCertificateIssuing(b *testing.B) {
 	certificate := ParseCertificate(cert.MustGenerate(nil, cert.Authority(true), cert.KeyUsage(x509.KeyUsageCertSign)))
 	certificate.Usage = Certificate_AUTHORITY_ISSUE
@@ -0,0 +1,363 @@
+package tls
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/gaukas/godicttls"
+	utls "github.com/refraction-networking/utls"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/platform/filesystem"
+)
+
+// Custom fingerprints are ClientHello specs in the JSON format of utls, e.g.
+//
+//	{
+//	  "cipher_suites": ["GREASE", "TLS_AES_128_GCM_SHA256", ...],
+//	  "compression_methods": ["NULL"],
+//	  "extensions": [
+//	    {"name": "GREASE"},
+//	    {"name": "server_name"},
+//	    {"name": "supported_groups", "named_group_list": ["GREASE", "x25519", "secp256r1"]},
+//	    {"name": "application_layer_protocol_negotiation", "protocol_name_list": ["h2", "http/1.1"]},
+//	    {"name": "key_share", "client_shares": [{"group": "GREASE", "key_exchange": [0]}, {"group": "x25519"}]},
+//	    {"id": 65037, "data": "base64 of the extension body"},
+//	    {"name": "padding", "len": 0},
+//	    ...
+//	  ]
+//	}
+//
+// Extensions utls cannot build from their name are given by "id" and sent as is.
+// GREASE values are randomized for every connection.
+type customFingerprint struct {
+	id   *utls.ClientHelloID
+	spec []byte
+}
+
+var customFingerprints = struct {
+	sync.Mutex
+	byName map[string]*customFingerprint
+	byID   map[*utls.ClientHelloID]*customFingerprint
+}{
+	byName: make(map[string]*customFingerprint),
+	byID:   make(map[*utls.ClientHelloID]*customFingerprint),
+}
+
+// fingerprintFilePrefix marks a fingerprint given by the path of a spec file.
+const fingerprintFilePrefix = "file:"
+
+// IsCustomFingerprint reports whether name is an inline ClientHello spec, an
+// absolute path of a spec file or a path prefixed with "file:", rather than the
+// name of a preset fingerprint.
+func IsCustomFingerprint(name string) bool {
+	return strings.HasPrefix(strings.TrimSpace(name), "{") ||
+		strings.HasPrefix(name, fingerprintFilePrefix) || filepath.IsAbs(name)
+}
+
+// LoadFingerprint returns the fingerprint of an inline ClientHello spec or of the
+// spec file at the given path. Specs are validated and cached on first use.
+func LoadFingerprint(name string) (*utls.ClientHelloID, error) {
+	customFingerprints.Lock()
+	defer customFingerprints.Unlock()
+
+	if fingerprint, found := customFingerprints.byName[name]; found {
+		return fingerprint.id, nil
+	}
+
+	spec := []byte(name)
+	if !strings.HasPrefix(strings.TrimSpace(name), "{") {
+		var err error
+		if spec, err = filesystem.ReadFile(strings.TrimPrefix(name, fingerprintFilePrefix)); err != nil {
+			return nil, newError("failed to read ClientHello spec ", name).Base(err)
+		}
+	}
+	if _, err := ParseClientHelloSpec(spec); err != nil {
+		return nil, newError("invalid ClientHello spec ", name).Base(err)
+	}
+
+	hash := sha256.Sum256(spec)
+	fingerprint := &customFingerprint{
+		id: &utls.ClientHelloID{
+			Client:  utls.HelloCustom.Client,
+			Version: hex.EncodeToString(hash[:8]),
+		},
+		spec: spec,
+	}
+	customFingerprints.byName[name] = fingerprint
+	customFingerprints.byID[fingerprint.id] = fingerprint
+	return fingerprint.id, nil
+}
+
+// NewUConn is like utls.UClient, but it also applies the ClientHello spec of a
+// custom fingerprint.
+func NewUConn(c net.Conn, config *utls.Config, fingerprint *utls.ClientHelloID) (*utls.UConn, error) {
+	uConn := utls.UClient(c, config, *fingerprint)
+	customFingerprints.Lock()
+	custom := customFingerprints.byID[fingerprint]
+	customFingerprints.Unlock()
+	if custom != nil {
+		// Extensions are modified when applied, so every connection gets its own spec.
+		spec, err := ParseClientHelloSpec(custom.spec)
+		if err != nil {
+			return nil, err
+		}
+		if err := uConn.ApplyPreset(spec); err != nil {
+			return nil, newError("failed to apply ClientHello spec").Base(err)
+		}
+	}
+	return uConn, nil
+}
+
+type clientHelloSpecJSON struct {
+	CipherSuites       *utls.CipherSuitesJSONUnmarshaler       `json:"cipher_suites"`
+	CompressionMethods *utls.CompressionMethodsJSONUnmarshaler `json:"compression_methods"`
+	Extensions         []json.RawMessage                       `json:"extensions"`
+	TLSVersMin         uint16                                  `json:"min_vers,omitempty"`
+	TLSVersMax         uint16                                  `json:"max_vers,omitempty"`
+}
+
+type genericExtensionJSON struct {
+	Name string  `json:"name,omitempty"`
+	ID   *uint16 `json:"id,omitempty"`
+	Data []byte  `json:"data,omitempty"`
+}
+
+// ParseClientHelloSpec parses a ClientHello spec in the JSON format of utls.
+func ParseClientHelloSpec(data []byte) (*utls.ClientHelloSpec, error) {
+	var specJSON clientHelloSpecJSON
+	if err := json.Unmarshal(data, &specJSON); err != nil {
+		return nil, err
+	}
+	if specJSON.CipherSuites == nil || len(specJSON.CipherSuites.CipherSuites()) == 0 {
+		return nil, newError("no cipher suites")
+	}
+	if len(specJSON.Extensions) == 0 {
+		return nil, newError("no extensions")
+	}
+
+	spec := &utls.ClientHelloSpec{
+		CipherSuites:       specJSON.CipherSuites.CipherSuites(),
+		CompressionMethods: []uint8{0},
+		TLSVersMin:         specJSON.TLSVersMin,
+		TLSVersMax:         specJSON.TLSVersMax,
+	}
+	if specJSON.CompressionMethods != nil {
+		spec.CompressionMethods = specJSON.CompressionMethods.CompressionMethods()
+	}
+	for i, raw := range specJSON.Extensions {
+		var generic genericExtensionJSON
+		if err := json.Unmarshal(raw, &generic); err != nil {
+			return nil, newError("invalid extension #", i).Base(err)
+		}
+		if generic.ID != nil && generic.Name != "GREASE" {
+			spec.Extensions = append(spec.Extensions, &utls.GenericExtension{Id: *generic.ID, Data: generic.Data})
+			continue
+		}
+		var extensions utls.TLSExtensionsJSONUnmarshaler
+		if err := json.Unmarshal(append(append([]byte{'['}, raw...), ']'), &extensions); err != nil {
+			return nil, newError("invalid extension ", generic.Name).Base(err)
+		}
+		spec.Extensions = append(spec.Extensions, extensions.Extensions()...)
+	}
+	return spec, nil
+}
+
+// MarshalClientHelloSpec formats spec in the JSON format read by
+// ParseClientHelloSpec. A pre_shared_key extension is left out, as it is only
+// valid for the session it was captured from.
+func MarshalClientHelloSpec(spec *utls.ClientHelloSpec) ([]byte, error) {
+	var specJSON struct {
+		CipherSuites       []string      `json:"cipher_suites"`
+		CompressionMethods []string      `json:"compression_methods"`
+		Extensions         []interface{} `json:"extensions"`
+		TLSVersMin         uint16        `json:"min_vers,omitempty"`
+		TLSVersMax         uint16        `json:"max_vers,omitempty"`
+	}
+	var err error
+	if specJSON.CipherSuites, err = names(spec.CipherSuites, godicttls.DictCipherSuiteValueIndexed); err != nil {
+		return nil, newError("unknown cipher suite").Base(err)
+	}
+	if specJSON.CompressionMethods, err = names(spec.CompressionMethods, godicttls.DictCompMethValueIndexed); err != nil {
+		return nil, newError("unknown compression method").Base(err)
+	}
+	specJSON.TLSVersMin = spec.TLSVersMin
+	specJSON.TLSVersMax = spec.TLSVersMax
+
+	for _, extension := range spec.Extensions {
+		if _, ok := extension.(utls.PreSharedKeyExtension); ok {
+			continue
+		}
+		ext, err := marshalExtension(extension)
+		if err != nil {
+			ext, err = marshalGenericExtension(extension)
+			if err != nil {
+				return nil, err
+			}
+		}
+		specJSON.Extensions = append(specJSON.Extensions, ext)
+	}
+	return json.MarshalIndent(specJSON, "", "  ")
+}
+
+type namedExtension map[string]interface{}
+
+func marshalExtension(extension utls.TLSExtension) (interface{}, error) {
+	var err error
+	switch ext := extension.(type) {
+	case *utls.UtlsGREASEExtension:
+		if len(ext.Body) > 0 {
+			return namedExtension{"name": "GREASE", "id": 0x0a0a, "data": ext.Body, "keep_data": true}, nil
+		}
+		return namedExtension{"name": "GREASE"}, nil
+	case *utls.SNIExtension:
+		return namedExtension{"name": "server_name"}, nil
+	case *utls.StatusRequestExtension:
+		return namedExtension{"name": "status_request"}, nil
+	case *utls.SCTExtension:
+		return namedExtension{"name": "signed_certificate_timestamp"}, nil
+	case *utls.ExtendedMasterSecretExtension:
+		return namedExtension{"name": "extended_master_secret"}, nil
+	case *utls.SessionTicketExtension:
+		return namedExtension{"name": "session_ticket"}, nil
+	case *utls.RenegotiationInfoExtension:
+		return namedExtension{"name": "renegotiation_info"}, nil
+	case *utls.UtlsPaddingExtension:
+		length := 0
+		if ext.GetPaddingLen == nil {
+			length = ext.PaddingLen
+		}
+		return namedExtension{"name": "padding", "len": length}, nil
+	case *utls.SupportedCurvesExtension:
+		var groups []string
+		if groups, err = names(ext.Curves, godicttls.DictSupportedGroupsValueIndexed); err != nil {
+			return nil, err
+		}
+		return namedExtension{"name": "supported_groups", "named_group_list": groups}, nil
+	case *utls.SupportedPointsExtension:
+		var formats []string
+		if formats, err = names(ext.SupportedPoints, godicttls.DictECPointFormatValueIndexed); err != nil {
+			return nil, err
+		}
+		return namedExtension{"name": "ec_point_formats", "ec_point_format_list": formats}, nil
+	case *utls.SignatureAlgorithmsExtension:
+		var schemes []string
+		if schemes, err = names(ext.SupportedSignatureAlgorithms, godicttls.DictSignatureSchemeValueIndexed); err != nil {
+			return nil, err
+		}
+		return namedExtension{"name": "signature_algorithms", "supported_signature_algorithms": schemes}, nil
+	case *utls.SignatureAlgorithmsCertExtension:
+		var schemes []string
+		if schemes, err = names(ext.SupportedSignatureAlgorithms, godicttls.DictSignatureSchemeValueIndexed); err != nil {
+			return nil, err
+		}
+		return namedExtension{"name": "signature_algorithms_cert", "supported_signature_algorithms": schemes}, nil
+	case *utls.FakeDelegatedCredentialsExtension:
+		var schemes []string
+		if schemes, err = names(ext.SupportedSignatureAlgorithms, godicttls.DictSignatureSchemeValueIndexed); err != nil {
+			return nil, err
+		}
+		return namedExtension{"name": "delegated_credentials", "supported_signature_algorithms": schemes}, nil
+	case *utls.ALPNExtension:
+		return namedExtension{"name": "application_layer_protocol_negotiation", "protocol_name_list": ext.AlpnProtocols}, nil
+	case *utls.ApplicationSettingsExtension:
+		return namedExtension{"name": "application_settings", "supported_protocols": ext.SupportedProtocols}, nil
+	case *utls.UtlsCompressCertExtension:
+		var algorithms []string
+		if algorithms, err = names(ext.Algorithms, godicttls.DictCertificateCompressionAlgorithmValueIndexed); err != nil {
+			return nil, err
+		}
+		return namedExtension{"name": "compress_certificate", "algorithms": algorithms}, nil
+	case *utls.PSKKeyExchangeModesExtension:
+		var modes []string
+		if modes, err = names(ext.Modes, godicttls.DictPSKKeyExchangeModeValueIndexed); err != nil {
+			return nil, err
+		}
+		return namedExtension{"name": "psk_key_exchange_modes", "ke_modes": modes}, nil
+	case *utls.SupportedVersionsExtension:
+		versions := make([]string, 0, len(ext.Versions))
+		for _, version := range ext.Versions {
+			name, found := versionNames[version]
+			if isGREASE(version) {
+				name, found = "GREASE", true
+			}
+			if !found {
+				return nil, newError("unknown TLS version ", version)
+			}
+			versions = append(versions, name)
+		}
+		return namedExtension{"name": "supported_versions", "versions": versions}, nil
+	case *utls.KeyShareExtension:
+		type clientShare struct {
+			Group       string `json:"group"`
+			KeyExchange []int  `json:"key_exchange,omitempty"`
+		}
+		shares := make([]clientShare, 0, len(ext.KeyShares))
+		for _, share := range ext.KeyShares {
+			if isGREASE(uint16(share.Group)) {
+				shares = append(shares, clientShare{Group: "GREASE", KeyExchange: []int{0}})
+				continue
+			}
+			name, found := godicttls.DictSupportedGroupsValueIndexed[uint16(share.Group)]
+			if !found {
+				return nil, newError("unknown key share group ", share.Group)
+			}
+			shares = append(shares, clientShare{Group: name})
+		}
+		return namedExtension{"name": "key_share", "client_shares": shares}, nil
+	case *utls.FakeRecordSizeLimitExtension:
+		return namedExtension{"name": "record_size_limit", "record_size_limit": ext.Limit}, nil
+	}
+	return nil, newError("no named form for extension")
+}
+
+// marshalGenericExtension gives the extension by id with its body as is.
+func marshalGenericExtension(extension utls.TLSExtension) (interface{}, error) {
+	if _, ok := extension.(*utls.KeyShareExtension); ok {
+		return nil, newError("key shares must be generated for each connection")
+	}
+	b := make([]byte, extension.Len())
+	if _, err := io.ReadFull(extension, b); err != nil && err != io.EOF {
+		return nil, err
+	}
+	if len(b) < 4 {
+		return nil, newError("extension too short")
+	}
+	id := uint16(b[0])<<8 | uint16(b[1])
+	return &genericExtensionJSON{
+		Name: godicttls.DictExtTypeValueIndexed[id],
+		ID:   &id,
+		Data: b[4:],
+	}, nil
+}
+
+var versionNames = map[uint16]string{
+	utls.VersionTLS13: "TLS 1.3",
+	utls.VersionTLS12: "TLS 1.2",
+	utls.VersionTLS11: "TLS 1.1",
+	utls.VersionTLS10: "TLS 1.0",
+}
+
+func isGREASE(v uint16) bool {
+	return v>>8 == v&0xff && v&0xf == 0xa
+}
+
+func names[T ~uint8 | ~uint16, D uint8 | uint16](values []T, dict map[D]string) ([]string, error) {
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if isGREASE(uint16(v)) {
+			result = append(result, "GREASE")
+			continue
+		}
+		name, found := dict[D(v)]
+		if !found {
+			return nil, newError("unknown value ", v)
+		}
+		result = append(result, name)
+	}
+	return result, nil
+}
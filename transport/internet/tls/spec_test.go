@@ -0,0 +1,73 @@
+package tls_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	utls "github.com/refraction-networking/utls"
+	"github.com/xtls/xray-core/common"
+	. "github.com/xtls/xray-core/transport/internet/tls"
+)
+
+func buildClientHello(fingerprint *utls.ClientHelloID) []byte {
+	uConn, err := NewUConn(nil, &utls.Config{ServerName: "www.example.com"}, fingerprint)
+	common.Must(err)
+	common.Must(uConn.BuildHandshakeState())
+	raw := uConn.HandshakeState.Hello.Raw
+	return append([]byte{0x16, 0x03, 0x01, byte(len(raw) >> 8), byte(len(raw))}, raw...)
+}
+
+func captureSpec(fingerprint *utls.ClientHelloID) []byte {
+	spec, err := (&utls.Fingerprinter{}).RawClientHello(buildClientHello(fingerprint))
+	common.Must(err)
+	specJSON, err := MarshalClientHelloSpec(spec)
+	common.Must(err)
+	return specJSON
+}
+
+func TestClientHelloSpecRoundTrip(t *testing.T) {
+	for _, preset := range []string{"hellochrome_102", "hellofirefox_105", "hellosafari_16_0"} {
+		specJSON := captureSpec(GetFingerprint(preset))
+		fingerprint, err := LoadFingerprint(string(specJSON))
+		if err != nil {
+			t.Fatal(preset, ": ", err)
+		}
+		if GetFingerprint(string(specJSON)) != fingerprint {
+			t.Error(preset, ": inline spec is not cached")
+		}
+		if again := captureSpec(fingerprint); !bytes.Equal(specJSON, again) {
+			t.Error(preset, ": ClientHello changed after round trip\n", string(specJSON), "\n", string(again))
+		}
+	}
+}
+
+func TestClientHelloSpecFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chrome.json")
+	common.Must(os.WriteFile(path, captureSpec(GetFingerprint("hellochrome_102")), 0o600))
+	if !IsCustomFingerprint(path) {
+		t.Fatal("expected ", path, " to be a custom fingerprint")
+	}
+	if GetFingerprint(path) == nil {
+		t.Error("failed to load ", path)
+	}
+	if GetFingerprint("file:"+path) == nil {
+		t.Error("failed to load file:", path)
+	}
+	for _, name := range []string{"chrome.json", "specs/chrome", "chrome"} {
+		if IsCustomFingerprint(name) {
+			t.Error("expected ", name, " not to be a custom fingerprint")
+		}
+	}
+
+	for _, spec := range []string{
+		`{"cipher_suites": [], "extensions": [{"name": "server_name"}]}`,
+		`{"cipher_suites": ["TLS_AES_128_GCM_SHA256"], "extensions": [{"name": "no_such_extension"}]}`,
+		filepath.Join(t.TempDir(), "missing.json"),
+	} {
+		if _, err := LoadFingerprint(spec); err == nil {
+			t.Error("expected an error for ", spec)
+		}
+	}
+}
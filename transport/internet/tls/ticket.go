@@ -0,0 +1,145 @@
+package tls
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xtls/xray-core/common/platform/filesystem"
+)
+
+const (
+	// SessionTicketSecretSize is the size of the secrets in session ticket key files.
+	SessionTicketSecretSize = 32
+
+	defaultTicketKeyRotation = 24 * time.Hour
+	ticketKeyFileCheckPeriod = time.Minute
+)
+
+// ParseSessionTicketSecrets parses the content of a session ticket key file:
+// base64 encoded secrets, one per line. Empty lines and lines starting with
+// '#' are ignored.
+func ParseSessionTicketSecrets(content []byte) ([][]byte, error) {
+	var secrets [][]byte
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		secret, err := base64.StdEncoding.DecodeString(line)
+		if err != nil {
+			if secret, err = base64.RawURLEncoding.DecodeString(line); err != nil {
+				return nil, newError("invalid session ticket secret").Base(err)
+			}
+		}
+		if len(secret) != SessionTicketSecretSize {
+			return nil, newError("session ticket secret must be ", SessionTicketSecretSize, " bytes, but got ", len(secret))
+		}
+		secrets = append(secrets, secret)
+	}
+	if len(secrets) == 0 {
+		return nil, newError("no session ticket secret")
+	}
+	return secrets, nil
+}
+
+// sessionTicketKeys keeps the session ticket keys of a server config in sync
+// with the key files and the rotation schedule. The keys are derived from the
+// secrets and the current period of wall-clock time, so servers sharing the
+// files rotate in step without coordination.
+type sessionTicketKeys struct {
+	sync.Mutex
+	files    []string
+	rotation time.Duration
+
+	content []byte
+	secrets [][]byte
+	checked time.Time
+	period  int64
+}
+
+func newSessionTicketKeys(c *Config) *sessionTicketKeys {
+	rotation := time.Duration(c.SessionTicketKeyRotation) * time.Second
+	if rotation <= 0 {
+		rotation = defaultTicketKeyRotation
+	}
+	return &sessionTicketKeys{
+		files:    c.SessionTicketKeyFiles,
+		rotation: rotation,
+		period:   -1,
+	}
+}
+
+// update reloads the key files if they may have changed, and sets the keys of
+// the current period to config.
+func (k *sessionTicketKeys) update(config *tls.Config, now time.Time) {
+	k.Lock()
+	defer k.Unlock()
+
+	changed := false
+	if now.Sub(k.checked) >= ticketKeyFileCheckPeriod {
+		k.checked = now
+		if content, secrets, err := k.load(); err != nil {
+			newError("failed to load session ticket keys").Base(err).AtError().WriteToLog()
+		} else if !bytes.Equal(content, k.content) {
+			k.content, k.secrets = content, secrets
+			changed = true
+		}
+	}
+	if len(k.secrets) == 0 {
+		return
+	}
+
+	period := now.UnixNano() / int64(k.rotation)
+	if !changed && period == k.period {
+		return
+	}
+	k.period = period
+
+	// Keys of the neighbouring periods still decrypt tickets, so that clocks
+	// of the servers don't have to be exact.
+	keys := make([][32]byte, 0, 3*len(k.secrets))
+	for _, secret := range k.secrets {
+		keys = append(keys, deriveTicketKey(secret, period), deriveTicketKey(secret, period-1), deriveTicketKey(secret, period+1))
+	}
+	config.SetSessionTicketKeys(keys)
+}
+
+func (k *sessionTicketKeys) load() ([]byte, [][]byte, error) {
+	var content []byte
+	for _, file := range k.files {
+		data, err := filesystem.ReadFile(file)
+		if err != nil {
+			return nil, nil, err
+		}
+		content = append(append(content, data...), '\n')
+	}
+	secrets, err := ParseSessionTicketSecrets(content)
+	if err != nil {
+		return nil, nil, err
+	}
+	return content, secrets, nil
+}
+
+func deriveTicketKey(secret []byte, period int64) (key [32]byte) {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("xray session ticket key"))
+	binary.Write(mac, binary.BigEndian, period)
+	copy(key[:], mac.Sum(nil))
+	return
+}
+
+// getConfigForClientFunc updates the session ticket keys of config before
+// each handshake, if necessary.
+func (k *sessionTicketKeys) getConfigForClientFunc(config *tls.Config) func(*tls.ClientHelloInfo) (*tls.Config, error) {
+	return func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		k.update(config, time.Now())
+		return nil, nil
+	}
+}
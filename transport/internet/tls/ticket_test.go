@@ -0,0 +1,81 @@
+package tls_test
+
+import (
+	"crypto/rand"
+	gotls "crypto/tls"
+	"encoding/base64"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/protocol/tls/cert"
+	. "github.com/xtls/xray-core/transport/internet/tls"
+)
+
+func writeTicketKeyFile(t *testing.T) string {
+	secret := make([]byte, SessionTicketSecretSize)
+	common.Must2(rand.Read(secret))
+	path := filepath.Join(t.TempDir(), "ticket.key")
+	common.Must(os.WriteFile(path, []byte(base64.StdEncoding.EncodeToString(secret)+"\n"), 0o600))
+	return path
+}
+
+func TestSessionTicketKeyFiles(t *testing.T) {
+	certificate := ParseCertificate(cert.MustGenerate(nil, cert.CommonName("www.example.com"), cert.DNSNames("www.example.com")))
+	certificate.OneTimeLoading = true
+	sharedFile := writeTicketKeyFile(t)
+	newServer := func(file string) *gotls.Config {
+		return (&Config{
+			Certificate:           []*Certificate{certificate},
+			SessionTicketKeyFiles: []string{file},
+		}).GetTLSConfig()
+	}
+
+	clientConfig := &gotls.Config{
+		ServerName:         "www.example.com",
+		InsecureSkipVerify: true,
+		ClientSessionCache: gotls.NewLRUClientSessionCache(1),
+	}
+	connect := func(serverConfig *gotls.Config) bool {
+		serverConn, clientConn := net.Pipe()
+		defer clientConn.Close()
+		go func() {
+			defer serverConn.Close()
+			conn := gotls.Server(serverConn, serverConfig)
+			if conn.Handshake() == nil {
+				conn.Write([]byte{0})
+			}
+		}()
+		conn := gotls.Client(clientConn, clientConfig)
+		// Reading makes the client process the session tickets.
+		if _, err := conn.Read(make([]byte, 1)); err != nil {
+			t.Fatal(err)
+		}
+		return conn.ConnectionState().DidResume
+	}
+
+	if connect(newServer(sharedFile)) {
+		t.Error("the first connection resumed")
+	}
+	if !connect(newServer(sharedFile)) {
+		t.Error("failed to resume on a server sharing the key file")
+	}
+	if connect(newServer(writeTicketKeyFile(t))) {
+		t.Error("resumed on a server with a different key file")
+	}
+}
+
+func TestParseSessionTicketSecrets(t *testing.T) {
+	if _, err := ParseSessionTicketSecrets([]byte("# comment\n\n")); err == nil {
+		t.Error("expected an error for a file without secrets")
+	}
+	if _, err := ParseSessionTicketSecrets([]byte(base64.StdEncoding.EncodeToString(make([]byte, 16)))); err == nil {
+		t.Error("expected an error for a short secret")
+	}
+	secrets, err := ParseSessionTicketSecrets([]byte("# current\n" + base64.StdEncoding.EncodeToString(make([]byte, 32)) + "\n" + base64.RawURLEncoding.EncodeToString(make([]byte, 32))))
+	if err != nil || len(secrets) != 2 {
+		t.Error("secrets: ", len(secrets), ", error: ", err)
+	}
+}
@@ -1,8 +1,10 @@
 package tls
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/tls"
+	"crypto/x509"
 	"math/big"
 
 	utls "github.com/refraction-networking/utls"
@@ -48,6 +50,36 @@ func (c *Conn) NegotiatedProtocol() (name string, mutual bool) {
 	return state.NegotiatedProtocol, state.NegotiatedProtocolIsMutual
 }
 
+// ClientIdentity completes the handshake and returns the name of the verified
+// client certificate: its subject common name, or else its first SAN. It is
+// empty if the client didn't present a certificate.
+func (c *Conn) ClientIdentity(ctx context.Context) (string, error) {
+	if err := c.HandshakeContext(ctx); err != nil {
+		return "", err
+	}
+	state := c.ConnectionState()
+	if len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return "", nil
+	}
+	return certificateIdentity(state.VerifiedChains[0][0]), nil
+}
+
+func certificateIdentity(cert *x509.Certificate) string {
+	switch {
+	case cert.Subject.CommonName != "":
+		return cert.Subject.CommonName
+	case len(cert.DNSNames) > 0:
+		return cert.DNSNames[0]
+	case len(cert.EmailAddresses) > 0:
+		return cert.EmailAddresses[0]
+	case len(cert.URIs) > 0:
+		return cert.URIs[0].String()
+	case len(cert.IPAddresses) > 0:
+		return cert.IPAddresses[0].String()
+	}
+	return ""
+}
+
 // Client initiates a TLS client handshake on the given connection.
 func Client(c net.Conn, config *tls.Config) net.Conn {
 	tlsConn := tls.Client(c, config)
@@ -107,18 +139,36 @@ func (c *UConn) NegotiatedProtocol() (name string, mutual bool) {
 	return state.NegotiatedProtocol, state.NegotiatedProtocolIsMutual
 }
 
-func UClient(c net.Conn, config *tls.Config, fingerprint *utls.ClientHelloID) net.Conn {
-	utlsConn := utls.UClient(c, copyConfig(config), *fingerprint)
-	return &UConn{UConn: utlsConn}
+func UClient(c net.Conn, config *tls.Config, fingerprint *utls.ClientHelloID) (net.Conn, error) {
+	utlsConn, err := NewUConn(c, copyConfig(config), fingerprint)
+	if err != nil {
+		return nil, err
+	}
+	return &UConn{UConn: utlsConn}, nil
 }
 
 func copyConfig(c *tls.Config) *utls.Config {
-	return &utls.Config{
+	config := &utls.Config{
 		RootCAs:               c.RootCAs,
 		ServerName:            c.ServerName,
 		InsecureSkipVerify:    c.InsecureSkipVerify,
 		VerifyPeerCertificate: c.VerifyPeerCertificate,
 	}
+	if c.GetClientCertificate != nil {
+		config.GetClientCertificate = func(*utls.CertificateRequestInfo) (*utls.Certificate, error) {
+			cert, err := c.GetClientCertificate(nil)
+			if err != nil {
+				return nil, err
+			}
+			return &utls.Certificate{
+				Certificate: cert.Certificate,
+				PrivateKey:  cert.PrivateKey,
+				OCSPStaple:  cert.OCSPStaple,
+				Leaf:        cert.Leaf,
+			}, nil
+		}
+	}
+	return config
 }
 
 func init() {
@@ -154,6 +204,12 @@ func GetFingerprint(name string) (fingerprint *utls.ClientHelloID) {
 	if fingerprint = OtherFingerprints[name]; fingerprint != nil {
 		return
 	}
+	if IsCustomFingerprint(name) {
+		var err error
+		if fingerprint, err = LoadFingerprint(name); err != nil {
+			newError("failed to load fingerprint").Base(err).AtWarning().WriteToLog()
+		}
+	}
 	return
 }
 
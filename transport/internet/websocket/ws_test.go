@@ -0,0 +1,125 @@
+package websocket_test
+
+import (
+	"context"
+	gonet "net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/transport/internet"
+	"github.com/xtls/xray-core/transport/internet/stat"
+	. "github.com/xtls/xray-core/transport/internet/websocket"
+)
+
+func pickPort() net.Port {
+	listener, err := gonet.Listen("tcp4", "127.0.0.1:0")
+	common.Must(err)
+	defer listener.Close()
+
+	addr := listener.Addr().(*gonet.TCPAddr)
+	return net.Port(addr.Port)
+}
+
+func echo(conn stat.Connection) {
+	go func(c stat.Connection) {
+		defer c.Close()
+
+		var b [1024]byte
+		for {
+			n, err := c.Read(b[:])
+			if err != nil {
+				return
+			}
+			if _, err := c.Write(b[:n]); err != nil {
+				return
+			}
+		}
+	}(conn)
+}
+
+func roundTrip(t *testing.T, conn net.Conn, payload string) {
+	t.Helper()
+
+	common.Must2(conn.Write([]byte(payload)))
+
+	var b [1024]byte
+	conn.SetReadDeadline(time.Now().Add(time.Second * 5))
+	n, err := conn.Read(b[:])
+	common.Must(err)
+	if string(b[:n]) != payload {
+		t.Error("response: ", string(b[:n]))
+	}
+}
+
+func TestListenWSAndDial(t *testing.T) {
+	port := pickPort()
+	listen, err := ListenWS(context.Background(), net.LocalHostIP, port, &internet.MemoryStreamConfig{
+		ProtocolName: "websocket",
+		ProtocolSettings: &Config{
+			Path: "ws",
+		},
+	}, echo)
+	common.Must(err)
+	defer listen.Close()
+
+	ctx := context.Background()
+	streamSettings := &internet.MemoryStreamConfig{
+		ProtocolName:     "websocket",
+		ProtocolSettings: &Config{Path: "ws"},
+	}
+	conn, err := Dial(ctx, net.TCPDestination(net.DomainAddress("localhost"), port), streamSettings)
+	common.Must(err)
+	roundTrip(t, conn, "Test connection 1")
+	common.Must(conn.Close())
+
+	<-time.After(time.Second * 1)
+
+	conn, err = Dial(ctx, net.TCPDestination(net.DomainAddress("localhost"), port), streamSettings)
+	common.Must(err)
+	roundTrip(t, conn, "Test connection 2")
+	common.Must(conn.Close())
+}
+
+func TestDialWithEarlyData(t *testing.T) {
+	port := pickPort()
+	listen, err := ListenWS(context.Background(), net.LocalHostIP, port, &internet.MemoryStreamConfig{
+		ProtocolName: "websocket",
+		ProtocolSettings: &Config{
+			Path: "/ed",
+		},
+	}, echo)
+	common.Must(err)
+	defer listen.Close()
+
+	conn, err := Dial(context.Background(), net.TCPDestination(net.LocalHostIP, port), &internet.MemoryStreamConfig{
+		ProtocolName:     "websocket",
+		ProtocolSettings: &Config{Path: "/ed", Ed: 2048},
+	})
+	common.Must(err)
+	defer conn.Close()
+
+	roundTrip(t, conn, "early data")
+	roundTrip(t, conn, "after early data")
+}
+
+func TestRequestHeader(t *testing.T) {
+	config := &Config{
+		Host: "example.com",
+		Header: []*Header{
+			{Key: "User-Agent", Value: "xray"},
+		},
+	}
+	header := config.GetRequestHeader()
+	if v := header.Get("Host"); v != "example.com" {
+		t.Error("unexpected Host header: ", v)
+	}
+	if v := header.Get("User-Agent"); v != "xray" {
+		t.Error("unexpected User-Agent header: ", v)
+	}
+	if _, found := header[http.CanonicalHeaderKey("Sec-WebSocket-Protocol")]; found {
+		t.Error("unexpected Sec-WebSocket-Protocol header")
+	}
+}